@@ -0,0 +1,163 @@
+package webgo
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// AccessLog writes one JSON line per request to W. High-traffic services
+// can sample by route or status class, and sensitive headers and query
+// parameters are redacted before anything is written.
+type AccessLog struct {
+	W io.Writer
+
+	// SampleRate is the fraction of requests logged when neither
+	// RouteRates nor StatusRates match; zero means every request.
+	SampleRate float64
+	// RouteRates overrides the rate per route pattern.
+	RouteRates map[string]float64
+	// StatusRates overrides the rate per status class (2 for 2xx...).
+	StatusRates map[int]float64
+
+	// Headers lists the request headers included in each line.
+	Headers       []string
+	RedactHeaders []string
+	RedactQuery   []string
+
+	mu sync.Mutex
+}
+
+func NewAccessLog(w io.Writer) *AccessLog {
+	return &AccessLog{
+		W:             w,
+		Headers:       []string{"User-Agent", "Referer"},
+		RedactHeaders: []string{"Authorization", "Cookie", "Proxy-Authorization", "X-Api-Key"},
+		RedactQuery:   append([]string(nil), sensitiveQueryParams...),
+	}
+}
+
+type accessEntry struct {
+	Time     time.Time         `json:"time"`
+	Remote   string            `json:"remote,omitempty"`
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Query    string            `json:"query,omitempty"`
+	Route    string            `json:"route,omitempty"`
+	Status   int               `json:"status"`
+	Bytes    int               `json:"bytes,omitempty"`
+	Duration float64           `json:"duration_ms"`
+	Headers  map[string]string `json:"headers,omitempty"`
+}
+
+func (al *AccessLog) rate(route string, status int) float64 {
+	if rate, ok := al.RouteRates[route]; ok {
+		return rate
+	}
+	if rate, ok := al.StatusRates[status/100]; ok {
+		return rate
+	}
+	if al.SampleRate == 0 {
+		return 1
+	}
+	return al.SampleRate
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (al *AccessLog) query(req *Request) string {
+	if req.raw == nil || req.raw.URL.RawQuery == "" {
+		return ""
+	}
+	q, _ := url.QueryUnescape(redactQuery(req.raw.URL.RawQuery, al.RedactQuery))
+	return q
+}
+
+// sensitiveQueryParams are the query parameters redacted by default.
+var sensitiveQueryParams = []string{"token", "access_token", "api_key", "password", "secret"}
+
+// redactQuery replaces the values of the parameters named in names,
+// ignoring case. A query with none of them is returned as it is.
+func redactQuery(rawQuery string, names []string) string {
+	values, err := url.ParseQuery(rawQuery)
+	changed := false
+	for name, vs := range values {
+		if containsFold(names, name) {
+			for i := range vs {
+				vs[i] = redacted
+			}
+			changed = true
+		}
+	}
+	if !changed && err == nil {
+		return rawQuery
+	}
+	return values.Encode()
+}
+
+func (al *AccessLog) headers(h http.Header) map[string]string {
+	if len(al.Headers) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(al.Headers))
+	for _, name := range al.Headers {
+		value := h.Get(name)
+		if value == "" {
+			continue
+		}
+		if containsFold(al.RedactHeaders, name) {
+			value = redacted
+		}
+		out[http.CanonicalHeaderKey(name)] = value
+	}
+	return out
+}
+
+func (al *AccessLog) Middleware(next ProcessFunc) ProcessFunc {
+	return func(req *Request) *Response {
+		start := req.Clock().Now()
+		resp := next(req)
+		rate := al.rate(req.Route(), resp.Status)
+		if rate <= 0 || (rate < 1 && rand.Float64() >= rate) {
+			return resp
+		}
+
+		entry := &accessEntry{
+			Time:     start,
+			Remote:   req.RemoteAddr(),
+			Method:   req.Method,
+			Path:     req.Path,
+			Query:    al.query(req),
+			Route:    req.Route(),
+			Status:   resp.Status,
+			Duration: float64(req.Clock().Since(start)) / float64(time.Millisecond),
+			Headers:  al.headers(req.Headers),
+		}
+		if size := responseSize(resp); size > 0 {
+			entry.Bytes = size
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return resp
+		}
+
+		al.mu.Lock()
+		al.W.Write(append(line, '\n'))
+		al.mu.Unlock()
+		return resp
+	}
+}
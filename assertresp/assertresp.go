@@ -0,0 +1,237 @@
+// Package assertresp provides test assertions for webgo responses. Each
+// assertion reports failures through t.Errorf and returns whether it
+// passed, so callers can stop early when later checks would be noise.
+package assertresp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/t4ng/webgo"
+)
+
+func Status(t webgo.TestingT, resp *webgo.Response, want int) bool {
+	t.Helper()
+	if resp.Status != want {
+		t.Errorf("status = %d, want %d; body: %s", resp.Status, want, abbreviate(body(resp)))
+		return false
+	}
+	return true
+}
+
+func Header(t webgo.TestingT, resp *webgo.Response, name, want string) bool {
+	t.Helper()
+	if got := resp.Headers.Get(name); got != want {
+		t.Errorf("header %s = %q, want %q", name, got, want)
+		return false
+	}
+	return true
+}
+
+func HeaderContains(t webgo.TestingT, resp *webgo.Response, name, substr string) bool {
+	t.Helper()
+	if got := resp.Headers.Get(name); !strings.Contains(got, substr) {
+		t.Errorf("header %s = %q, want it to contain %q", name, got, substr)
+		return false
+	}
+	return true
+}
+
+func HeaderMatches(t webgo.TestingT, resp *webgo.Response, name, pattern string) bool {
+	t.Helper()
+	got := resp.Headers.Get(name)
+	if ok, err := regexp.MatchString(pattern, got); err != nil || !ok {
+		t.Errorf("header %s = %q, want a match for %q", name, got, pattern)
+		return false
+	}
+	return true
+}
+
+func NoHeader(t webgo.TestingT, resp *webgo.Response, name string) bool {
+	t.Helper()
+	if values, ok := resp.Headers[http.CanonicalHeaderKey(name)]; ok {
+		t.Errorf("header %s = %q, want it absent", name, values)
+		return false
+	}
+	return true
+}
+
+func Body(t webgo.TestingT, resp *webgo.Response, want string) bool {
+	t.Helper()
+	if got := string(body(resp)); got != want {
+		t.Errorf("body = %q, want %q", abbreviate([]byte(got)), want)
+		return false
+	}
+	return true
+}
+
+func BodyContains(t webgo.TestingT, resp *webgo.Response, substr string) bool {
+	t.Helper()
+	if b := body(resp); !bytes.Contains(b, []byte(substr)) {
+		t.Errorf("body %q does not contain %q", abbreviate(b), substr)
+		return false
+	}
+	return true
+}
+
+// JSON compares the response body with want after both are decoded, so
+// key order and formatting do not matter.
+func JSON(t webgo.TestingT, resp *webgo.Response, want interface{}) bool {
+	t.Helper()
+	var got interface{}
+	if err := json.Unmarshal(body(resp), &got); err != nil {
+		t.Errorf("body is not JSON: %v; body: %s", err, abbreviate(body(resp)))
+		return false
+	}
+	norm, err := normalize(want)
+	if err != nil {
+		t.Errorf("encoding expected value: %v", err)
+		return false
+	}
+	if !reflect.DeepEqual(got, norm) {
+		t.Errorf("JSON body = %s, want %s", encode(got), encode(norm))
+		return false
+	}
+	return true
+}
+
+// JSONPath checks the value at path in a JSON document. body may be a
+// *webgo.Response, a []byte or a string. want is compared by its JSON
+// encoding, so JSONPath(t, body, "$.items[0].id", 3) matches 3.0.
+func JSONPath(t webgo.TestingT, body interface{}, path string, want interface{}) bool {
+	t.Helper()
+	doc, err := document(body)
+	if err != nil {
+		t.Errorf("%s: %v", path, err)
+		return false
+	}
+	got, err := Lookup(doc, path)
+	if err != nil {
+		t.Errorf("%s: %v", path, err)
+		return false
+	}
+	norm, err := normalize(want)
+	if err != nil {
+		t.Errorf("%s: encoding expected value: %v", path, err)
+		return false
+	}
+	if !reflect.DeepEqual(got, norm) {
+		t.Errorf("%s = %s, want %s", path, encode(got), encode(norm))
+		return false
+	}
+	return true
+}
+
+var pathTokenRe = regexp.MustCompile(`^(?:\.([A-Za-z_$][\w$-]*)|\[(-?\d+)\]|\['([^']*)'\]|\["([^"]*)"\])`)
+
+// Lookup evaluates a JSONPath subset on a decoded document: "$" followed
+// by .name, ['name'] and [index] steps. Negative indexes count from the
+// end.
+func Lookup(doc interface{}, path string) (interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path must start with $")
+	}
+	rest := path[1:]
+	cur := doc
+	for rest != "" {
+		m := pathTokenRe.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, fmt.Errorf("invalid path at %q", rest)
+		}
+		rest = rest[len(m[0]):]
+
+		if m[2] != "" {
+			i, _ := strconv.Atoi(m[2])
+			arr, ok := cur.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("[%d] applied to %s", i, kind(cur))
+			}
+			if i < 0 {
+				i += len(arr)
+			}
+			if i < 0 || i >= len(arr) {
+				return nil, fmt.Errorf("index %s out of range (length %d)", m[2], len(arr))
+			}
+			cur = arr[i]
+			continue
+		}
+
+		key := m[1] + m[3] + m[4]
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("field %q applied to %s", key, kind(cur))
+		}
+		if cur, ok = obj[key]; !ok {
+			return nil, fmt.Errorf("no field %q", key)
+		}
+	}
+	return cur, nil
+}
+
+func document(v interface{}) (interface{}, error) {
+	var b []byte
+	switch v := v.(type) {
+	case *webgo.Response:
+		b = body(v)
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return v, nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("body is not JSON: %v", err)
+	}
+	return doc, nil
+}
+
+func body(resp *webgo.Response) []byte {
+	if resp.BodyReader != nil {
+		b, _ := ioutil.ReadAll(resp.BodyReader)
+		resp.Body, resp.BodyReader = b, nil
+	}
+	return resp.Body
+}
+
+func normalize(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
+
+func encode(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func kind(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func abbreviate(b []byte) string {
+	if len(b) > 200 {
+		return string(b[:200]) + "..."
+	}
+	return string(b)
+}
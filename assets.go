@@ -0,0 +1,138 @@
+package webgo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type asset struct {
+	name    string
+	hash    string
+	modTime time.Time
+}
+
+// Assets serves the files of fsys under prefix, each also reachable under a
+// content-hashed name (app.js -> app.3f9a2c1e.js) that can be cached
+// forever.
+type Assets struct {
+	prefix  string
+	fsys    fs.FS
+	names   map[string]*asset
+	hashed  map[string]*asset
+	started time.Time
+}
+
+func NewAssets(prefix string, fsys fs.FS) (*Assets, error) {
+	a := &Assets{
+		prefix:  strings.TrimRight(prefix, "/"),
+		fsys:    fsys,
+		names:   make(map[string]*asset),
+		hashed:  make(map[string]*asset),
+		started: time.Now(),
+	}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		entry := &asset{
+			name:    name,
+			hash:    hex.EncodeToString(h.Sum(nil))[:8],
+			modTime: info.ModTime(),
+		}
+		a.names[name] = entry
+		a.hashed[fingerprint(name, entry.hash)] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func fingerprint(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// Path returns the fingerprinted URL of the named asset, or its plain URL
+// if the asset is unknown.
+func (a *Assets) Path(name string) string {
+	name = strings.TrimLeft(name, "/")
+	if entry, ok := a.names[name]; ok {
+		return a.prefix + "/" + fingerprint(name, entry.hash)
+	}
+	return a.prefix + "/" + name
+}
+
+func (a *Assets) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"asset": a.Path,
+	}
+}
+
+func (a *Assets) Process(req *Request) *Response {
+	if len(req.Arguments) == 0 {
+		return NotFound("")
+	}
+	name := req.Arguments[len(req.Arguments)-1]
+
+	entry, immutable := a.hashed[name]
+	if !immutable {
+		if entry = a.names[name]; entry == nil {
+			return NotFound("")
+		}
+	}
+
+	f, err := a.fsys.Open(entry.name)
+	if err != nil {
+		return NotFound("")
+	}
+	modTime := entry.modTime
+	if modTime.IsZero() {
+		modTime = a.started
+	}
+	var resp *Response
+	if rs, ok := f.(io.ReadSeeker); ok {
+		resp = Content(entry.name, modTime, rs)
+	} else {
+		body, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return InternalError(err)
+		}
+		resp = Content(entry.name, modTime, strings.NewReader(string(body)))
+	}
+
+	resp.Headers.Set("ETag", `"`+entry.hash+`"`)
+	if immutable {
+		resp.Headers.Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		resp.Headers.Set("Cache-Control", "no-cache")
+	}
+	return resp
+}
+
+func (app *Application) ServeAssets(a *Assets) {
+	app.Route("GET "+regexp.QuoteMeta(a.prefix)+"/(.+)", a.Process)
+}
@@ -0,0 +1,222 @@
+package webgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+type AuditEvent struct {
+	Time       time.Time              `json:"time"`
+	Actor      string                 `json:"actor,omitempty"`
+	Action     string                 `json:"action"`
+	Resource   string                 `json:"resource,omitempty"`
+	Outcome    string                 `json:"outcome"`
+	Method     string                 `json:"method,omitempty"`
+	Route      string                 `json:"route,omitempty"`
+	RemoteAddr string                 `json:"remote_addr,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+type AuditSink interface {
+	WriteAudit(event *AuditEvent) error
+}
+
+// AddAuditSink adds sink to those receiving events. An HTTPSink logs
+// delivery failures through the application and is flushed on shutdown.
+func (app *Application) AddAuditSink(sink AuditSink) {
+	if s, ok := sink.(*HTTPSink); ok {
+		s.logf = app.logf
+		app.OnStop(s.Flush)
+	}
+	app.auditSinks = append(app.auditSinks, sink)
+}
+
+// SetAuditActor sets how the acting user is identified for events that
+// don't name one.
+func (app *Application) SetAuditActor(fn func(*Request) string) {
+	app.auditActor = fn
+}
+
+// Audit records event, filling in the time, request details and actor
+// where the handler left them empty, and hands it to every sink.
+func (req *Request) Audit(event AuditEvent) {
+	if req.app == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = req.Clock().Now()
+	}
+	if event.Outcome == "" {
+		event.Outcome = "success"
+	}
+	if event.Method == "" {
+		event.Method = req.Method
+	}
+	if event.Route == "" {
+		event.Route = req.Route()
+	}
+	if event.RemoteAddr == "" {
+		event.RemoteAddr = req.RemoteAddr()
+	}
+	if event.Actor == "" && req.app.auditActor != nil {
+		event.Actor = req.app.auditActor(req)
+	}
+	for _, sink := range req.app.auditSinks {
+		if err := sink.WriteAudit(&event); err != nil {
+			req.app.logf("webgo: audit sink: %v", err)
+		}
+	}
+}
+
+// WriterSink writes events as JSON lines.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// NewFileSink appends events to the file at path as JSON lines.
+func NewFileSink(path string) (*WriterSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterSink(f), nil
+}
+
+func (s *WriterSink) WriteAudit(event *AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// HTTPSink posts each event as JSON to a collector URL. Events are queued
+// and delivered in order by a background worker, so a slow collector
+// doesn't hold up the request recording them.
+type HTTPSink struct {
+	URL     string
+	Headers http.Header
+	Client  *http.Client
+	Timeout time.Duration
+	// QueueSize bounds the events awaiting delivery; once it is full
+	// WriteAudit drops the event and returns an error. Zero means 1024.
+	QueueSize int
+
+	once  sync.Once
+	queue chan auditDelivery
+	logf  func(format string, args ...interface{})
+}
+
+// auditDelivery is an event body to post, or a flush marker when done is
+// set.
+type auditDelivery struct {
+	body []byte
+	done chan struct{}
+}
+
+func (s *HTTPSink) start() {
+	s.once.Do(func() {
+		size := s.QueueSize
+		if size <= 0 {
+			size = 1024
+		}
+		s.queue = make(chan auditDelivery, size)
+		go s.run()
+	})
+}
+
+func (s *HTTPSink) WriteAudit(event *AuditEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.start()
+	select {
+	case s.queue <- auditDelivery{body: body}:
+		return nil
+	default:
+		return errors.New("audit queue full, event dropped")
+	}
+}
+
+// Flush waits until every event queued before it has been delivered, or
+// ctx is done.
+func (s *HTTPSink) Flush(ctx context.Context) error {
+	s.start()
+	done := make(chan struct{})
+	select {
+	case s.queue <- auditDelivery{done: done}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *HTTPSink) run() {
+	for d := range s.queue {
+		if d.done != nil {
+			close(d.done)
+			continue
+		}
+		if err := s.post(d.body); err != nil {
+			logf := s.logf
+			if logf == nil {
+				logf = log.Printf
+			}
+			logf("webgo: audit sink: %v", err)
+		}
+	}
+}
+
+func (s *HTTPSink) post(body []byte) error {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	r, err := http.NewRequestWithContext(ctx, "POST", s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range s.Headers {
+		r.Header[name] = values
+	}
+	r.Header.Set("Content-Type", "application/json")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(r)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("audit collector responded %s", resp.Status)
+	}
+	return nil
+}
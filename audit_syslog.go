@@ -0,0 +1,29 @@
+//go:build !windows && !plan9
+
+package webgo
+
+import (
+	"encoding/json"
+	"log/syslog"
+)
+
+// SyslogSink sends events as JSON to the local syslog daemon.
+type SyslogSink struct {
+	w *syslog.Writer
+}
+
+func NewSyslogSink(tag string) (*SyslogSink, error) {
+	w, err := syslog.New(syslog.LOG_NOTICE|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogSink{w: w}, nil
+}
+
+func (s *SyslogSink) WriteAudit(event *AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.w.Notice(string(line))
+}
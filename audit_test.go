@@ -0,0 +1,71 @@
+package webgo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHTTPSinkQueuesAndFlushes(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	received := 0
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		mu.Lock()
+		received++
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	sink := &HTTPSink{URL: collector.URL, QueueSize: 4}
+	app := NewApplication()
+	app.AddAuditSink(sink)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := sink.WriteAudit(&AuditEvent{Action: "login"}); err != nil {
+			t.Fatalf("WriteAudit: %v", err)
+		}
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("WriteAudit blocked for %v on a slow collector", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	if err := sink.Flush(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Flush before delivery = %v, want %v", err, context.DeadlineExceeded)
+	}
+	cancel()
+
+	close(release)
+	if err := app.runStopHooks(context.Background()); err != nil {
+		t.Fatalf("stop hooks: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if received != 3 {
+		t.Errorf("collector received %d events, want 3", received)
+	}
+}
+
+func TestHTTPSinkQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer collector.Close()
+	defer close(release)
+
+	sink := &HTTPSink{URL: collector.URL, QueueSize: 1}
+	var err error
+	for i := 0; i < 4 && err == nil; i++ {
+		err = sink.WriteAudit(&AuditEvent{Action: "login"})
+	}
+	if err == nil {
+		t.Error("WriteAudit on a full queue returned no error")
+	}
+}
@@ -0,0 +1,125 @@
+package webgo
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+type User interface {
+	UserID() string
+	Roles() []string
+}
+
+// UserProvider looks users up for Auth. FindUser returns a nil User for
+// IDs that no longer exist; Authenticate returns ErrInvalidCredentials
+// for a bad username or password.
+type UserProvider interface {
+	FindUser(ctx context.Context, id string) (User, error)
+	Authenticate(ctx context.Context, username, password string) (User, error)
+}
+
+// Auth resolves the current user from the session. Its middleware must run
+// inside the Sessions middleware (and RememberMe's, if used).
+type Auth struct {
+	Provider UserProvider
+
+	// LoginPath is where RequireAuth sends browsers; requests that don't
+	// accept HTML get a 401 instead.
+	LoginPath string
+}
+
+func NewAuth(provider UserProvider) *Auth {
+	return &Auth{Provider: provider, LoginPath: "/login"}
+}
+
+func (a *Auth) Middleware(next ProcessFunc) ProcessFunc {
+	return func(req *Request) *Response {
+		req.auth = a
+		return next(req)
+	}
+}
+
+// User returns the logged-in user, or nil. It is looked up once per
+// request.
+func (req *Request) User() User {
+	if req.userLoaded || req.auth == nil {
+		return req.user
+	}
+	req.userLoaded = true
+	id := req.Session().UserID()
+	if id == "" {
+		return nil
+	}
+	user, err := req.auth.Provider.FindUser(req.Context(), id)
+	if err != nil {
+		if req.app != nil {
+			req.app.logf("webgo: loading user %s: %v", id, err)
+		}
+		return nil
+	}
+	req.user = user
+	return user
+}
+
+// Login checks the credentials and, if they are valid, logs the user in
+// to the session, which gets a new ID.
+func (a *Auth) Login(req *Request, username, password string) (User, error) {
+	user, err := a.Provider.Authenticate(req.Context(), username, password)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+	req.Session().Login(user.UserID())
+	req.user, req.userLoaded = user, true
+	return user, nil
+}
+
+func (a *Auth) Logout(req *Request) {
+	req.Session().Logout()
+	req.user, req.userLoaded = nil, true
+}
+
+// RequireAuth rejects requests without a logged-in user.
+func (a *Auth) RequireAuth(next ProcessFunc) ProcessFunc {
+	return func(req *Request) *Response {
+		if req.User() == nil {
+			return a.unauthenticated(req)
+		}
+		return next(req)
+	}
+}
+
+// RequireRole rejects requests whose user has none of roles.
+func (a *Auth) RequireRole(roles ...string) Middleware {
+	return func(next ProcessFunc) ProcessFunc {
+		return func(req *Request) *Response {
+			user := req.User()
+			if user == nil {
+				return a.unauthenticated(req)
+			}
+			for _, role := range user.Roles() {
+				if containsString(roles, role) {
+					return next(req)
+				}
+			}
+			return Forbidden("")
+		}
+	}
+}
+
+func (a *Auth) unauthenticated(req *Request) *Response {
+	if a.LoginPath != "" && req.Method == "GET" && strings.Contains(req.Headers.Get("Accept"), "text/html") {
+		target := req.Path
+		if req.raw != nil && req.raw.URL.RawQuery != "" {
+			target += "?" + req.raw.URL.RawQuery
+		}
+		return Redirect(a.LoginPath + "?next=" + url.QueryEscape(target))
+	}
+	return Unauthorized("")
+}
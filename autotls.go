@@ -0,0 +1,83 @@
+//go:build autotls
+
+package webgo
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// CertCache stores ACME certificates; autocert.DirCache is the disk
+// implementation. Only built with the autotls build tag, like RunAutoTLS.
+type CertCache = autocert.Cache
+
+func (app *Application) SetCertCache(cache CertCache) {
+	app.certCache = cache
+}
+
+func defaultCertCache() CertCache {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return autocert.DirCache(filepath.Join(dir, "webgo-autocert"))
+}
+
+// RunAutoTLS serves HTTPS on :443 with certificates obtained from Let's
+// Encrypt for domains. A plaintext listener on :80 answers the HTTP-01
+// challenges and redirects everything else to HTTPS. Only built with the
+// autotls build tag.
+func (app *Application) RunAutoTLS(domains ...string) error {
+	if err := app.runStartHooks(); err != nil {
+		return err
+	}
+	cache, _ := app.certCache.(CertCache)
+	if cache == nil {
+		cache = defaultCertCache()
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      cache,
+	}
+
+	httpL, err := app.listen("tcp", ":http")
+	if err != nil {
+		return err
+	}
+	httpsL, err := app.listen("tcp", ":https")
+	if err != nil {
+		httpL.Close()
+		return err
+	}
+	redirect := app.newServer(m.HTTPHandler(nil))
+	redirect.TLSConfig = nil
+
+	tlsConfig := m.TLSConfig()
+	if base := app.httpServer.TLSConfig; base != nil {
+		cfg := base.Clone()
+		cfg.GetCertificate = tlsConfig.GetCertificate
+		cfg.NextProtos = append(cfg.NextProtos, tlsConfig.NextProtos...)
+		tlsConfig = cfg
+	}
+	app.httpServer.TLSConfig = tlsConfig
+	app.setAddr(httpsL.Addr())
+	app.notifyReady()
+
+	errc := make(chan error, 2)
+	go func() {
+		errc <- redirect.Serve(app.wrapListener(httpL))
+	}()
+	go func() {
+		errc <- app.httpServer.ServeTLS(app.wrapListener(httpsL), "", "")
+	}()
+	err = <-errc
+	if err != http.ErrServerClosed {
+		app.httpServer.Close()
+		redirect.Close()
+	}
+	return app.serveResult(err)
+}
@@ -0,0 +1,347 @@
+package webgo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type BalanceStrategy int
+
+const (
+	RoundRobin BalanceStrategy = iota
+	LeastConnections
+	WeightedRoundRobin
+)
+
+var ErrNoBackend = errors.New("no healthy backend")
+
+// Backend is one upstream of a LoadBalancer.
+type Backend struct {
+	URL    string
+	Weight int
+
+	target  *url.URL
+	id      string
+	active  atomic.Int64
+	mu      sync.Mutex
+	fails   int
+	ejected time.Time
+	down    bool
+	current int
+}
+
+type BackendStatus struct {
+	URL     string `json:"url"`
+	Healthy bool   `json:"healthy"`
+	Active  int64  `json:"active"`
+}
+
+// LoadBalancer proxies requests over several backends. A backend is taken
+// out of rotation for EjectFor after MaxFails consecutive connection
+// failures, and while active health checks, if enabled, fail.
+type LoadBalancer struct {
+	Strategy BalanceStrategy
+	Proxy    ProxyOptions
+	MaxFails int
+	EjectFor time.Duration
+
+	// AffinityCookie and AffinityHeader, if set, pin a client to the
+	// backend named by that cookie or header while it stays healthy. The
+	// cookie is set by the balancer; the header is expected to carry a
+	// stable client key, hashed onto the backends.
+	AffinityCookie string
+	AffinityHeader string
+
+	// Retries is how many other backends an idempotent request is sent to
+	// after a connection error, waiting RetryBackoff, doubled each time,
+	// in between. Bodies on StreamBody routes can't be sent twice, so
+	// requests with one are never retried.
+	Retries      int
+	RetryBackoff time.Duration
+
+	backends []*Backend
+	next     atomic.Uint64
+	wrrMu    sync.Mutex
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewLoadBalancer(strategy BalanceStrategy, backends ...*Backend) (*LoadBalancer, error) {
+	if len(backends) == 0 {
+		return nil, errors.New("webgo: load balancer needs at least one backend")
+	}
+	for _, b := range backends {
+		u, err := url.Parse(b.URL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, errors.New("webgo: invalid backend URL " + b.URL)
+		}
+		b.target = u
+		if b.Weight <= 0 {
+			b.Weight = 1
+		}
+		sum := sha256.Sum256([]byte(b.URL))
+		b.id = hex.EncodeToString(sum[:8])
+	}
+	return &LoadBalancer{
+		Strategy:     strategy,
+		MaxFails:     3,
+		EjectFor:     30 * time.Second,
+		RetryBackoff: 50 * time.Millisecond,
+		backends:     backends,
+		stop:         make(chan struct{}),
+	}, nil
+}
+
+func (lb *LoadBalancer) Process(req *Request) *Response {
+	resp, err := serveCached(req, lb.Proxy.Cache, lb.serve)
+	if err != nil {
+		return proxyError(req, err)
+	}
+	return resp
+}
+
+func (lb *LoadBalancer) serve(req *Request) (*Response, error) {
+	pinned := lb.affinity(req)
+	b := pinned
+	if b == nil {
+		b = lb.pick(nil)
+	}
+	if b == nil {
+		return nil, ErrNoBackend
+	}
+
+	tried := []*Backend{b}
+	resp, err := lb.forward(req, b)
+	backoff := lb.RetryBackoff
+	// a streamed body may be partly sent already, and can't be sent again
+	retry := idempotent(req.Method) && req.bodyReplayable()
+	for attempt := 0; err != nil && attempt < lb.Retries && retry; attempt++ {
+		select {
+		case <-req.Context().Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if b = lb.pick(tried); b == nil {
+			break
+		}
+		tried = append(tried, b)
+		resp, err = lb.forward(req, b)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if lb.AffinityCookie != "" && b != pinned {
+		resp.SetCookie(&http.Cookie{Name: lb.AffinityCookie, Value: b.id, Path: "/", HttpOnly: true})
+	}
+	return resp, nil
+}
+
+// affinity returns the healthy backend the request is pinned to, if any.
+func (lb *LoadBalancer) affinity(req *Request) *Backend {
+	now := time.Now()
+	if lb.AffinityCookie != "" {
+		if c, err := req.Cookie(lb.AffinityCookie); err == nil {
+			for _, b := range lb.backends {
+				if b.id == c.Value && b.healthy(now) {
+					return b
+				}
+			}
+		}
+	}
+	if lb.AffinityHeader != "" {
+		if key := req.Headers.Get(lb.AffinityHeader); key != "" {
+			// rendezvous hashing keeps most keys in place when a backend
+			// leaves or returns
+			var best *Backend
+			var bestScore uint64
+			for _, b := range lb.healthyBackends() {
+				sum := sha256.Sum256([]byte(key + "|" + b.id))
+				if score := binary.BigEndian.Uint64(sum[:8]); best == nil || score > bestScore {
+					best, bestScore = b, score
+				}
+			}
+			return best
+		}
+	}
+	return nil
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+func (lb *LoadBalancer) forward(req *Request, b *Backend) (*Response, error) {
+	b.active.Add(1)
+	resp, err := forward(req, b.target, lb.Proxy.transport(), lb.Proxy, func() { b.active.Add(-1) })
+	if err != nil {
+		b.active.Add(-1)
+		if req.Context().Err() == nil {
+			lb.failed(b)
+		}
+		return nil, err
+	}
+	lb.succeeded(b)
+	return resp, nil
+}
+
+func (b *Backend) healthy(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.down && !now.Before(b.ejected)
+}
+
+func (lb *LoadBalancer) failed(b *Backend) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fails++
+	if lb.MaxFails > 0 && b.fails >= lb.MaxFails {
+		b.ejected = time.Now().Add(lb.EjectFor)
+		b.fails = 0
+	}
+}
+
+func (lb *LoadBalancer) succeeded(b *Backend) {
+	b.mu.Lock()
+	b.fails = 0
+	b.mu.Unlock()
+}
+
+func (lb *LoadBalancer) healthyBackends() []*Backend {
+	now := time.Now()
+	var out []*Backend
+	for _, b := range lb.backends {
+		if b.healthy(now) {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// pick chooses a healthy backend, avoiding those in exclude unless no
+// other is left.
+func (lb *LoadBalancer) pick(exclude []*Backend) *Backend {
+	backends := lb.healthyBackends()
+	if len(exclude) > 0 {
+		var rest []*Backend
+		for _, b := range backends {
+			excluded := false
+			for _, e := range exclude {
+				excluded = excluded || b == e
+			}
+			if !excluded {
+				rest = append(rest, b)
+			}
+		}
+		if len(rest) > 0 {
+			backends = rest
+		}
+	}
+	if len(backends) == 0 {
+		return nil
+	}
+
+	switch lb.Strategy {
+	case LeastConnections:
+		// start the scan at a rotating offset so ties are spread out
+		offset := int(lb.next.Add(1))
+		var best *Backend
+		for i := range backends {
+			b := backends[(offset+i)%len(backends)]
+			if best == nil || b.active.Load() < best.active.Load() {
+				best = b
+			}
+		}
+		return best
+	case WeightedRoundRobin:
+		// smooth weighted round robin, as in nginx
+		lb.wrrMu.Lock()
+		defer lb.wrrMu.Unlock()
+		total := 0
+		var best *Backend
+		for _, b := range backends {
+			b.current += b.Weight
+			total += b.Weight
+			if best == nil || b.current > best.current {
+				best = b
+			}
+		}
+		best.current -= total
+		return best
+	}
+	return backends[int(lb.next.Add(1)-1)%len(backends)]
+}
+
+// CheckHealth probes every backend with a GET of path each interval,
+// marking it down until it answers with a 2xx status again.
+func (lb *LoadBalancer) CheckHealth(path string, interval, timeout time.Duration) {
+	client := &http.Client{Transport: lb.Proxy.Transport, Timeout: timeout}
+	check := func() {
+		var wg sync.WaitGroup
+		for _, b := range lb.backends {
+			wg.Add(1)
+			go func(b *Backend) {
+				defer wg.Done()
+				ok := false
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+				u := *b.target
+				u.Path = path
+				r, _ := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+				if resp, err := client.Do(r); err == nil {
+					resp.Body.Close()
+					ok = resp.StatusCode >= 200 && resp.StatusCode < 300
+				}
+				b.mu.Lock()
+				b.down = !ok
+				if ok {
+					b.ejected = time.Time{}
+				}
+				b.mu.Unlock()
+			}(b)
+		}
+		wg.Wait()
+	}
+
+	go func() {
+		check()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				check()
+			case <-lb.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the health checks.
+func (lb *LoadBalancer) Close() error {
+	lb.stopOnce.Do(func() { close(lb.stop) })
+	return nil
+}
+
+func (lb *LoadBalancer) Backends() []BackendStatus {
+	now := time.Now()
+	out := make([]BackendStatus, len(lb.backends))
+	for i, b := range lb.backends {
+		out[i] = BackendStatus{URL: b.URL, Healthy: b.healthy(now), Active: b.active.Load()}
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package webgo
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// hangUpServer reads a little of each request, then drops the connection
+// without answering.
+func hangUpServer(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			c.Read(make([]byte, 64))
+			c.Close()
+		}
+	}()
+	return "http://" + l.Addr().String()
+}
+
+func TestLoadBalancerRetriesOnlyReplayableBodies(t *testing.T) {
+	body := strings.Repeat("payload ", 1000)
+	for _, tt := range []struct {
+		name     string
+		streamed bool
+		status   int
+		received string
+	}{
+		{"Buffered", false, 200, body},
+		{"Streamed", true, 502, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				received = string(b)
+			}))
+			defer good.Close()
+
+			// the dead backend's weight makes it the first pick
+			lb, err := NewLoadBalancer(WeightedRoundRobin,
+				&Backend{URL: hangUpServer(t), Weight: 10},
+				&Backend{URL: good.URL, Weight: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			lb.Retries, lb.RetryBackoff = 1, 0
+
+			app := NewApplication()
+			var opts []RouteOption
+			if tt.streamed {
+				opts = append(opts, WithStreamBody())
+			}
+			app.Route("PUT /upload", lb.Process, opts...)
+
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest("PUT", "/upload", strings.NewReader(body)))
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if received != tt.received {
+				t.Errorf("backend received %d bytes, want %d", len(received), len(tt.received))
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package webgo
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for the application and its middleware.
+// Tests substitute a FakeClock to control time instead of sleeping.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock is the real wall clock, used unless another is set.
+var SystemClock Clock = systemClock{}
+
+func WithClock(c Clock) Option {
+	return func(app *Application) {
+		app.SetClock(c)
+	}
+}
+
+func (app *Application) SetClock(c Clock) {
+	app.clock = c
+}
+
+func (app *Application) Clock() Clock {
+	if app.clock == nil {
+		return SystemClock
+	}
+	return app.clock
+}
+
+// Clock returns the clock of the application serving req.
+func (req *Request) Clock() Clock {
+	if req.app == nil {
+		return SystemClock
+	}
+	return req.app.Clock()
+}
+
+// FakeClock is a Clock that only moves when told to. Channels returned by
+// After fire once Advance or Set moves the time past their deadline.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewFakeClock returns a FakeClock set to start, or to a fixed date if
+// start is zero.
+func NewFakeClock(start time.Time) *FakeClock {
+	if start.IsZero() {
+		start = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return &FakeClock{now: start}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	deadline := c.now.Add(d)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{deadline, ch})
+	return ch
+}
+
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	now := c.now.Add(d)
+	c.mu.Unlock()
+	c.Set(now)
+}
+
+// Set moves the clock to t, firing every After channel that has come due.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+	sort.Slice(c.waiters, func(i, j int) bool {
+		return c.waiters[i].deadline.Before(c.waiters[j].deadline)
+	})
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(t) {
+			pending = append(pending, w)
+		} else {
+			w.ch <- t
+		}
+	}
+	c.waiters = pending
+}
+
+// Waiters reports how many After channels have not fired yet, so tests
+// can wait until the code under test is blocked on the clock.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
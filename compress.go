@@ -0,0 +1,198 @@
+package webgo
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type CompressOptions struct {
+	// Algorithms lists the encodings offered, in order of preference:
+	// "gzip" and "deflate". Empty means both, gzip first.
+	Algorithms []string
+
+	// Level trades CPU for size, from 1 for the fastest to 9 for the
+	// smallest output; zero means the default level. TypeLevels overrides
+	// it for particular media types.
+	Level      int
+	TypeLevels map[string]int
+
+	// Types lists the media types compressed; a trailing "/*" matches a
+	// whole family. Empty means text, JSON, JavaScript, XML and SVG.
+	Types []string
+
+	// MinSize leaves smaller bodies as they are; zero means 1KB.
+	MinSize int
+}
+
+// Compress compresses buffered response bodies with the best encoding
+// the client accepts. Use it on the application for a global setting and
+// on routes to tune them, like level 1 for a busy JSON API and level 9
+// for a rarely fetched sitemap; responses that are already encoded, such
+// as those of a route with its own Compress, are left alone. Streamed
+// bodies and files are sent as they are.
+func Compress(opts CompressOptions) Middleware {
+	if len(opts.Algorithms) == 0 {
+		opts.Algorithms = []string{"gzip", "deflate"}
+	}
+	for _, alg := range opts.Algorithms {
+		if alg != "gzip" && alg != "deflate" {
+			panic(fmt.Sprintf("webgo: unsupported compression algorithm %q", alg))
+		}
+	}
+	checkCompressLevel(opts.Level)
+	for _, level := range opts.TypeLevels {
+		checkCompressLevel(level)
+	}
+	if opts.MinSize == 0 {
+		opts.MinSize = 1024
+	}
+
+	return func(next ProcessFunc) ProcessFunc {
+		return func(req *Request) *Response {
+			resp := next(req)
+			if resp.StreamFunc != nil || resp.BodyReader != nil || resp.serveHTTP != nil ||
+				len(resp.Body) < opts.MinSize || !bodyAllowed(resp.Status) ||
+				resp.Headers.Get("Content-Encoding") != "" {
+				return resp
+			}
+			mediaType, _, _ := strings.Cut(resp.Headers.Get("Content-Type"), ";")
+			mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+			if !compressibleType(mediaType, opts.Types) {
+				return resp
+			}
+			resp.AddHeader("Vary", "Accept-Encoding")
+			alg := acceptedEncoding(req.Headers.Get("Accept-Encoding"), opts.Algorithms)
+			if alg == "" {
+				return resp
+			}
+			level := opts.Level
+			if l, ok := opts.TypeLevels[mediaType]; ok {
+				level = l
+			}
+			compressBody(resp, alg, level)
+			return resp
+		}
+	}
+}
+
+func checkCompressLevel(level int) {
+	if level < 0 || level > 9 {
+		panic(fmt.Sprintf("webgo: invalid compression level %d", level))
+	}
+}
+
+var defaultCompressTypes = []string{
+	"text/*",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+}
+
+func compressibleType(mediaType string, types []string) bool {
+	if mediaType == "" {
+		return false
+	}
+	if len(types) == 0 {
+		if strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+			return true
+		}
+		types = defaultCompressTypes
+	}
+	for _, t := range types {
+		if t == mediaType || (strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptedEncoding returns the first of algorithms the Accept-Encoding
+// header allows, or "" if there is none.
+func acceptedEncoding(header string, algorithms []string) string {
+	if header == "" {
+		return ""
+	}
+	q := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		weight := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				weight = f
+			}
+		}
+		q[name] = weight
+	}
+	for _, alg := range algorithms {
+		weight, ok := q[alg]
+		if !ok {
+			weight, ok = q["*"]
+		}
+		if ok && weight > 0 {
+			return alg
+		}
+	}
+	return ""
+}
+
+// compressors pools gzip and flate writers per algorithm and level, as
+// they are expensive to set up.
+var compressors sync.Map
+
+type compressor interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+func compressorPool(alg string, level int) *sync.Pool {
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+	key := alg + strconv.Itoa(level)
+	if p, ok := compressors.Load(key); ok {
+		return p.(*sync.Pool)
+	}
+	p, _ := compressors.LoadOrStore(key, &sync.Pool{New: func() interface{} {
+		var c compressor
+		if alg == "gzip" {
+			c, _ = gzip.NewWriterLevel(ioutil.Discard, level)
+		} else {
+			c, _ = flate.NewWriter(ioutil.Discard, level)
+		}
+		return c
+	}})
+	return p.(*sync.Pool)
+}
+
+// compressBody replaces resp's body with its encoding by alg, unless that
+// turns out no smaller.
+func compressBody(resp *Response, alg string, level int) {
+	pool := compressorPool(alg, level)
+	c := pool.Get().(compressor)
+	defer pool.Put(c)
+	buf := getBuffer()
+	c.Reset(buf)
+	if _, err := c.Write(resp.Body); err != nil || c.Close() != nil || buf.Len() >= len(resp.Body) {
+		putBuffer(buf)
+		return
+	}
+
+	if resp.buf != nil {
+		putBuffer(resp.buf)
+	}
+	resp.Body, resp.buf = buf.Bytes(), buf
+	resp.Headers.Set("Content-Encoding", alg)
+	resp.Headers.Del("Content-Length")
+	// the tag names the uncompressed representation
+	if etag := resp.Headers.Get("ETag"); strings.HasPrefix(etag, `"`) {
+		resp.Headers.Set("ETag", "W/"+etag)
+	}
+}
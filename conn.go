@@ -0,0 +1,144 @@
+package webgo
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func (app *Application) SetKeepAlivesEnabled(enabled bool) {
+	app.httpServer.SetKeepAlivesEnabled(enabled)
+}
+
+// SetMaxConnections caps the number of simultaneously open connections.
+// Further connections wait in the listen backlog until one closes.
+func (app *Application) SetMaxConnections(n int) {
+	app.maxConns = n
+}
+
+// SetConnReadTimeout bounds how long any single read on a connection may
+// block while it waits for a request, on top of the server's own
+// per-request deadlines. Once a request has started arriving, only those
+// apply: reads made while a handler runs, such as the server's check for
+// a closed connection, must not time out or the request's context would
+// be canceled.
+func (app *Application) SetConnReadTimeout(d time.Duration) {
+	app.connReadTimeout = d
+}
+
+func (app *Application) wrapListener(l net.Listener) net.Listener {
+	if app.maxConns > 0 {
+		l = &limitListener{Listener: l, slots: make(chan struct{}, app.maxConns)}
+	}
+	if app.connReadTimeout > 0 {
+		l = &deadlineListener{Listener: l, timeout: app.connReadTimeout}
+	}
+	if app.proxyProtocol {
+		l = &proxyListener{Listener: l, trusted: app.proxyTrusted}
+	}
+	return l
+}
+
+type limitListener struct {
+	net.Listener
+	slots chan struct{}
+}
+
+func (l *limitListener) Accept() (net.Conn, error) {
+	l.slots <- struct{}{}
+	c, err := l.Listener.Accept()
+	if err != nil {
+		<-l.slots
+		return nil, err
+	}
+	return &limitConn{Conn: c, release: func() { <-l.slots }}, nil
+}
+
+type limitConn struct {
+	net.Conn
+	release func()
+	once    sync.Once
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+type deadlineListener struct {
+	net.Listener
+	timeout time.Duration
+}
+
+func (l *deadlineListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &deadlineConn{Conn: c, timeout: l.timeout}, nil
+}
+
+// deadlineConn applies its timeout to every Read while the connection
+// waits for a request, unless the deadline the server asked for is
+// earlier. Otherwise reads have the server's deadline.
+type deadlineConn struct {
+	net.Conn
+	timeout  time.Duration
+	busy     atomic.Bool
+	mu       sync.Mutex
+	deadline time.Time
+	applied  bool
+}
+
+func (c *deadlineConn) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	switch {
+	case !c.busy.Load():
+		deadline := time.Now().Add(c.timeout)
+		if !c.deadline.IsZero() && c.deadline.Before(deadline) {
+			deadline = c.deadline
+		}
+		c.Conn.SetReadDeadline(deadline)
+		c.applied = true
+	case c.applied:
+		c.Conn.SetReadDeadline(c.deadline)
+		c.applied = false
+	}
+	c.mu.Unlock()
+	return c.Conn.Read(p)
+}
+
+// trackConnState tells deadlineConns whether they are serving a request.
+// The server may hand it a *tls.Conn wrapping one, which NetConn unwraps.
+func trackConnState(c net.Conn, state http.ConnState) {
+	for {
+		switch conn := c.(type) {
+		case *deadlineConn:
+			conn.busy.Store(state == http.StateActive)
+			return
+		case *proxyConn:
+			c = conn.Conn
+		case interface{ NetConn() net.Conn }:
+			c = conn.NetConn()
+		default:
+			return
+		}
+	}
+}
+
+func (c *deadlineConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.deadline, c.applied = t, false
+	c.mu.Unlock()
+	return c.Conn.SetReadDeadline(t)
+}
+
+func (c *deadlineConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.deadline, c.applied = t, false
+	c.mu.Unlock()
+	return c.Conn.SetDeadline(t)
+}
@@ -0,0 +1,49 @@
+package webgo
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConnReadTimeoutSparesRunningHandlers(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	for _, tt := range []struct {
+		name string
+		tls  bool
+	}{
+		{"Plain", false},
+		{"TLS", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewApplication()
+			app.SetConnReadTimeout(timeout)
+			app.Route("GET /slow", func(req *Request) *Response {
+				time.Sleep(3 * timeout)
+				if err := req.Context().Err(); err != nil {
+					return Text(500, err.Error())
+				}
+				return Text(200, "done")
+			})
+
+			ts := httptest.NewUnstartedServer(app)
+			ts.Listener = app.wrapListener(ts.Listener)
+			ts.Config.ConnState = trackConnState
+			if tt.tls {
+				ts.StartTLS()
+			} else {
+				ts.Start()
+			}
+			defer ts.Close()
+
+			resp, err := ts.Client().Get(ts.URL + "/slow")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != 200 {
+				t.Errorf("got status %d, want 200", resp.StatusCode)
+			}
+		})
+	}
+}
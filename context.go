@@ -0,0 +1,34 @@
+package webgo
+
+import (
+	"context"
+	"net"
+)
+
+// SetBaseContext sets the function providing the root context of each
+// listener; request contexts derive from it.
+func (app *Application) SetBaseContext(fn func(net.Listener) context.Context) {
+	app.httpServer.BaseContext = fn
+}
+
+// SetConnContext sets a function deriving the context of every accepted
+// connection, e.g. to attach per-connection metadata.
+func (app *Application) SetConnContext(fn func(ctx context.Context, c net.Conn) context.Context) {
+	app.httpServer.ConnContext = fn
+}
+
+// Context returns the request's context. It is canceled when the client
+// goes away or the handler returns.
+func (req *Request) Context() context.Context {
+	if req.ctx != nil {
+		return req.ctx
+	}
+	if req.raw != nil {
+		return req.raw.Context()
+	}
+	return context.Background()
+}
+
+func (req *Request) SetContext(ctx context.Context) {
+	req.ctx = ctx
+}
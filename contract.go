@@ -0,0 +1,319 @@
+package webgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LoadOpenAPI reads a JSON OpenAPI document, e.g. one committed to the
+// repository and checked by a Contract.
+func LoadOpenAPI(r io.Reader) (*OpenAPISpec, error) {
+	spec := &OpenAPISpec{}
+	if err := json.NewDecoder(r).Decode(spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// Contract validates requests and responses against an OpenAPI document.
+// It is meant for development and tests: violations are logged and the
+// response is replaced by a 400 (request) or 500 (response) listing them,
+// so drift between implementation and spec cannot go unnoticed.
+type Contract struct {
+	// OnViolation, if set, is called instead of failing the request.
+	OnViolation func(req *Request, violations []string)
+
+	spec  *OpenAPISpec
+	paths []contractPath
+}
+
+type contractPath struct {
+	re     *regexp.Regexp
+	names  []string
+	params int
+	ops    map[string]*OpenAPIOperation
+}
+
+var pathParamRe = regexp.MustCompile(`\{([^}/]+)\}`)
+
+func NewContract(spec *OpenAPISpec) *Contract {
+	c := &Contract{spec: spec}
+	for template, ops := range spec.Paths {
+		var names []string
+		pattern := "^"
+		last := 0
+		for _, m := range pathParamRe.FindAllStringSubmatchIndex(template, -1) {
+			pattern += regexp.QuoteMeta(template[last:m[0]]) + "([^/]+)"
+			names = append(names, template[m[2]:m[3]])
+			last = m[1]
+		}
+		pattern += regexp.QuoteMeta(strings.TrimRight(template[last:], "/")) + "$"
+		c.paths = append(c.paths, contractPath{regexp.MustCompile(pattern), names, len(names), ops})
+	}
+	// literal paths win over templated ones, as in OpenAPI itself
+	sort.SliceStable(c.paths, func(i, j int) bool {
+		return c.paths[i].params < c.paths[j].params
+	})
+	return c
+}
+
+func (c *Contract) Middleware(next ProcessFunc) ProcessFunc {
+	return func(req *Request) *Response {
+		op, args, found := c.operation(req)
+		if !found {
+			return next(req)
+		}
+		if op == nil {
+			if resp := c.fail(req, 400, []string{fmt.Sprintf("%s %s is not in the API document", req.Method, req.Path)}); resp != nil {
+				return resp
+			}
+			return next(req)
+		}
+		if violations := c.checkRequest(req, op, args); len(violations) > 0 {
+			if resp := c.fail(req, 400, violations); resp != nil {
+				return resp
+			}
+		}
+
+		resp := next(req)
+		if violations := c.checkResponse(resp, op); len(violations) > 0 {
+			if failed := c.fail(req, 500, violations); failed != nil {
+				return failed
+			}
+		}
+		return resp
+	}
+}
+
+func (c *Contract) fail(req *Request, status int, violations []string) *Response {
+	if c.OnViolation != nil {
+		c.OnViolation(req, violations)
+		return nil
+	}
+	if req.app != nil {
+		req.app.logf("webgo: contract violation on %s %s: %s", req.Method, req.Path, strings.Join(violations, "; "))
+	}
+	detail := "request does not match the API document"
+	if status >= 500 {
+		detail = "response does not match the API document"
+	}
+	return RespondProblem(NewProblem(status, detail).With("violations", violations))
+}
+
+// operation finds the operation for req. found is false when the path is
+// not in the document at all, which is left alone; a documented path with
+// an undocumented method returns found with a nil operation.
+func (c *Contract) operation(req *Request) (*OpenAPIOperation, map[string]string, bool) {
+	path := strings.TrimRight(req.Path, "/")
+	for _, p := range c.paths {
+		m := p.re.FindStringSubmatch(path)
+		if m == nil {
+			continue
+		}
+		args := make(map[string]string, len(p.names))
+		for i, name := range p.names {
+			args[name] = m[i+1]
+		}
+		return p.ops[strings.ToLower(req.Method)], args, true
+	}
+	return nil, nil, false
+}
+
+func (c *Contract) checkRequest(req *Request, op *OpenAPIOperation, args map[string]string) []string {
+	var violations []string
+	for _, param := range op.Parameters {
+		var value string
+		var present bool
+		switch param.In {
+		case "path":
+			value, present = args[param.Name]
+		case "query":
+			value, present = req.Query[param.Name]
+		case "header":
+			value = req.Headers.Get(param.Name)
+			present = value != ""
+		default:
+			continue
+		}
+		if !present {
+			if param.Required {
+				violations = append(violations, fmt.Sprintf("missing required %s parameter %q", param.In, param.Name))
+			}
+			continue
+		}
+		if err := checkParam(param.Schema, value); err != "" {
+			violations = append(violations, fmt.Sprintf("%s parameter %q: %s", param.In, param.Name, err))
+		}
+	}
+
+	if op.RequestBody == nil {
+		return violations
+	}
+	body, err := req.bodyBytes()
+	if err != nil {
+		return append(violations, fmt.Sprintf("reading request body: %v", err))
+	}
+	if len(body) == 0 {
+		if op.RequestBody.Required {
+			violations = append(violations, "missing required request body")
+		}
+		return violations
+	}
+	return append(violations, c.checkBody("request body", op.RequestBody.Content, req.Headers.Get("Content-Type"), body)...)
+}
+
+func (c *Contract) checkResponse(resp *Response, op *OpenAPIOperation) []string {
+	doc := op.Responses[strconv.Itoa(resp.Status)]
+	if doc == nil {
+		doc = op.Responses[strconv.Itoa(resp.Status/100)+"XX"]
+	}
+	if doc == nil {
+		doc = op.Responses["default"]
+	}
+	if doc == nil {
+		return []string{fmt.Sprintf("status %d is not documented", resp.Status)}
+	}
+	if len(doc.Content) == 0 || resp.BodyReader != nil || resp.StreamFunc != nil || len(resp.Body) == 0 {
+		// streamed bodies can't be checked without buffering them
+		return nil
+	}
+	return c.checkBody("response body", doc.Content, resp.Headers.Get("Content-Type"), resp.Body)
+}
+
+func (c *Contract) checkBody(what string, content map[string]*OpenAPIMediaType, contentType string, body []byte) []string {
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	media, ok := content[mediaType]
+	if !ok {
+		var types []string
+		for t := range content {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		return []string{fmt.Sprintf("%s has content type %q, want one of %s", what, mediaType, strings.Join(types, ", "))}
+	}
+	if media.Schema == nil || !strings.Contains(mediaType, "json") {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return []string{fmt.Sprintf("%s is not valid JSON: %v", what, err)}
+	}
+	return c.checkSchema(what, media.Schema, v, 0)
+}
+
+func checkParam(schema *OpenAPISchema, value string) string {
+	if schema == nil {
+		return ""
+	}
+	switch schema.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Sprintf("%q is not an integer", value)
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Sprintf("%q is not a number", value)
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Sprintf("%q is not a boolean", value)
+		}
+	}
+	return ""
+}
+
+func (c *Contract) checkSchema(path string, schema *OpenAPISchema, v interface{}, depth int) []string {
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		if c.spec.Components == nil || c.spec.Components.Schemas[name] == nil {
+			return []string{fmt.Sprintf("%s: unresolved reference %s", path, schema.Ref)}
+		}
+		if depth > 64 {
+			return nil
+		}
+		return c.checkSchema(path, c.spec.Components.Schemas[name], v, depth+1)
+	}
+	if v == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s is null", path)}
+	}
+
+	var violations []string
+	mismatch := func(want string) []string {
+		return []string{fmt.Sprintf("%s is %s, want %s", path, jsonKind(v), want)}
+	}
+	switch schema.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch("object")
+		}
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				violations = append(violations, fmt.Sprintf("%s is missing required field %q", path, name))
+			}
+		}
+		for name, value := range obj {
+			if prop, ok := schema.Properties[name]; ok {
+				violations = append(violations, c.checkSchema(path+"."+name, prop, value, depth+1)...)
+			} else if schema.AdditionalProperties != nil {
+				violations = append(violations, c.checkSchema(path+"."+name, schema.AdditionalProperties, value, depth+1)...)
+			}
+		}
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			return mismatch("array")
+		}
+		if schema.Items != nil {
+			for i, item := range arr {
+				violations = append(violations, c.checkSchema(fmt.Sprintf("%s[%d]", path, i), schema.Items, item, depth+1)...)
+			}
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			return mismatch("string")
+		}
+	case "integer":
+		if n, ok := v.(float64); !ok || n != math.Trunc(n) {
+			return mismatch("integer")
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return mismatch("number")
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return mismatch("boolean")
+		}
+	}
+	sort.Strings(violations)
+	return violations
+}
+
+func jsonKind(v interface{}) string {
+	switch n := v.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case float64:
+		if n == math.Trunc(n) {
+			return "an integer"
+		}
+		return "a number"
+	}
+	return "null"
+}
@@ -0,0 +1,61 @@
+package webgo
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrNoCookie         = http.ErrNoCookie
+	ErrInvalidSignature = errors.New("invalid cookie signature")
+)
+
+func (resp *Response) SetCookie(c *http.Cookie) *Response {
+	if v := c.String(); v != "" {
+		resp.AddHeader("Set-Cookie", v)
+	}
+	return resp
+}
+
+// DeleteCookie tells the client to drop the cookie by expiring it. The path
+// has to match the one it was set with.
+func (resp *Response) DeleteCookie(name, path string) *Response {
+	return resp.SetCookie(&http.Cookie{
+		Name:    name,
+		Value:   "",
+		Path:    path,
+		Expires: time.Unix(0, 0),
+		MaxAge:  -1,
+	})
+}
+
+// SetSignedCookie sets c with its value signed with key. It is
+// SetSecureCookie with a SecureCodec holding just that key, so the two
+// share one format; use a codec directly to rotate keys or encrypt
+// values. A value too big for a cookie is not set, as SetSecureCookie
+// would report.
+func (resp *Response) SetSignedCookie(c *http.Cookie, key []byte) *Response {
+	resp.SetSecureCookie(c, signingCodec(key))
+	return resp
+}
+
+func (req *Request) Cookie(name string) (*http.Cookie, error) {
+	r := http.Request{Header: req.Headers}
+	return r.Cookie(name)
+}
+
+func (req *Request) Cookies() []*http.Cookie {
+	r := http.Request{Header: req.Headers}
+	return r.Cookies()
+}
+
+// SignedCookie returns the verified value of a cookie set with
+// SetSignedCookie.
+func (req *Request) SignedCookie(name string, key []byte) (string, error) {
+	return req.SecureCookie(name, signingCodec(key))
+}
+
+func signingCodec(key []byte) *SecureCodec {
+	return &SecureCodec{Clock: SystemClock, keys: []cookieKey{{hash: key}}}
+}
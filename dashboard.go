@@ -0,0 +1,153 @@
+package webgo
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const recentRequests = 100
+
+type RecentRequest struct {
+	Time     time.Time     `json:"time"`
+	Method   string        `json:"method"`
+	Path     string        `json:"path"`
+	Route    string        `json:"route"`
+	Status   int           `json:"status"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+type requestLog struct {
+	mu      sync.Mutex
+	entries []RecentRequest
+	next    int
+}
+
+func (rl *requestLog) middleware(next ProcessFunc) ProcessFunc {
+	return func(req *Request) *Response {
+		start := req.Clock().Now()
+		resp := next(req)
+		entry := RecentRequest{
+			Time:     start,
+			Method:   req.Method,
+			Path:     req.Path,
+			Route:    req.Route(),
+			Status:   resp.Status,
+			Duration: req.Clock().Since(start),
+		}
+
+		rl.mu.Lock()
+		if len(rl.entries) < recentRequests {
+			rl.entries = append(rl.entries, entry)
+		} else {
+			rl.entries[rl.next] = entry
+			rl.next = (rl.next + 1) % recentRequests
+		}
+		rl.mu.Unlock()
+		return resp
+	}
+}
+
+// recent returns the logged requests, newest first.
+func (rl *requestLog) recent() []RecentRequest {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	out := make([]RecentRequest, 0, len(rl.entries))
+	for i := len(rl.entries) - 1; i >= 0; i-- {
+		out = append(out, rl.entries[(rl.next+i)%len(rl.entries)])
+	}
+	return out
+}
+
+type DashboardData struct {
+	Started    time.Time       `json:"started"`
+	Uptime     string          `json:"uptime"`
+	Goroutines int             `json:"goroutines"`
+	HeapAlloc  uint64          `json:"heap_alloc"`
+	HeapSys    uint64          `json:"heap_sys"`
+	NumGC      uint32          `json:"num_gc"`
+	InFlight   int64           `json:"in_flight"`
+	Routes     []string        `json:"routes"`
+	Stats      []RouteStats    `json:"stats"`
+	Recent     []RecentRequest `json:"recent"`
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"errorRate": func(s RouteStats) string {
+		if s.Requests == 0 {
+			return "0%"
+		}
+		return fmt.Sprintf("%.1f%%", float64(s.Errors)*100/float64(s.Requests))
+	},
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta http-equiv="refresh" content="5">
+<title>webgo dashboard</title>
+<style>
+body{font:14px sans-serif;margin:2em;color:#222}
+table{border-collapse:collapse;margin-bottom:2em}
+td,th{border:1px solid #ccc;padding:4px 8px;text-align:left}
+.err{color:#b00}
+</style></head><body>
+<h1>webgo</h1>
+<p>up {{.Uptime}} &middot; {{.Goroutines}} goroutines &middot; heap {{.HeapAlloc}} / {{.HeapSys}} bytes &middot; {{.NumGC}} GCs &middot; {{.InFlight}} in flight</p>
+<h2>Routes</h2>
+<table><tr><th>pattern</th></tr>{{range .Routes}}<tr><td>{{.}}</td></tr>{{end}}</table>
+<h2>Statistics</h2>
+<table><tr><th>method</th><th>route</th><th>requests</th><th>errors</th><th>error rate</th><th>p50</th><th>p95</th><th>p99</th></tr>
+{{range .Stats}}<tr><td>{{.Method}}</td><td>{{.Route}}</td><td>{{.Requests}}</td><td>{{.Errors}}</td><td>{{errorRate .}}</td><td>{{.P50}}</td><td>{{.P95}}</td><td>{{.P99}}</td></tr>{{end}}
+</table>
+<h2>Recent requests</h2>
+<table><tr><th>time</th><th>method</th><th>path</th><th>route</th><th>status</th><th>duration</th></tr>
+{{range .Recent}}<tr{{if ge .Status 500}} class="err"{{end}}><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Method}}</td><td>{{.Path}}</td><td>{{.Route}}</td><td>{{.Status}}</td><td>{{.Duration}}</td></tr>{{end}}
+</table>
+</body></html>`))
+
+func (app *Application) dashboardData(started time.Time, log *requestLog) *DashboardData {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	routes := make([]string, 0, len(app.table().processors))
+	for _, p := range app.table().processors {
+		routes = append(routes, p.Pattern)
+	}
+	return &DashboardData{
+		Started:    started,
+		Uptime:     time.Since(started).Round(time.Second).String(),
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  mem.HeapAlloc,
+		HeapSys:    mem.HeapSys,
+		NumGC:      mem.NumGC,
+		InFlight:   app.InFlight(),
+		Routes:     routes,
+		Stats:      app.Stats(),
+		Recent:     log.recent(),
+	}
+}
+
+// EnableDashboard serves a live HTML overview of routes, statistics,
+// recent requests and runtime health at prefix, and the same data as JSON
+// at prefix/data.json. It is meant for development and internal use; pass
+// an authentication middleware as guard before exposing it anywhere else.
+func (app *Application) EnableDashboard(prefix string, guards ...Middleware) {
+	started := time.Now()
+	log := &requestLog{}
+	app.Use(log.middleware)
+	app.EnableStats("")
+
+	prefix = regexp.QuoteMeta(strings.TrimRight(prefix, "/"))
+
+	app.Route("GET "+prefix+"/data.json", func(req *Request) *Response {
+		return JSON(200, app.dashboardData(started, log))
+	}, WithRouteMiddleware(guards...))
+	app.Route("GET "+prefix, func(req *Request) *Response {
+		var buf bytes.Buffer
+		if err := dashboardTemplate.Execute(&buf, app.dashboardData(started, log)); err != nil {
+			return InternalError(err)
+		}
+		return HTML(200, buf.String())
+	}, WithRouteMiddleware(guards...))
+}
@@ -0,0 +1,36 @@
+package webgo
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"regexp"
+	"strings"
+)
+
+// EnableDebugEndpoints mounts the net/http/pprof profiles under
+// prefix/pprof/ and expvar under prefix/vars. Any middleware given, e.g.
+// an authentication check, guards all of them.
+func (app *Application) EnableDebugEndpoints(prefix string, guards ...Middleware) {
+	prefix = regexp.QuoteMeta(strings.TrimRight(prefix, "/"))
+	index := WrapHandler(http.HandlerFunc(pprof.Index))
+	named := map[string]ProcessFunc{
+		"cmdline": WrapHandler(http.HandlerFunc(pprof.Cmdline)),
+		"profile": WrapHandler(http.HandlerFunc(pprof.Profile)),
+		"symbol":  WrapHandler(http.HandlerFunc(pprof.Symbol)),
+		"trace":   WrapHandler(http.HandlerFunc(pprof.Trace)),
+	}
+	// pprof.Index only serves named profiles under /debug/pprof/, so
+	// dispatch them here to support any prefix
+	app.Route(".* "+prefix+"/pprof/?([^/]*)", func(req *Request) *Response {
+		name := req.Arguments[0]
+		if name == "" {
+			return index(req)
+		}
+		if handler, ok := named[name]; ok {
+			return handler(req)
+		}
+		return WrapHandler(pprof.Handler(name))(req)
+	}, WithRouteMiddleware(guards...))
+	app.Route("GET "+prefix+"/vars", WrapHandler(expvar.Handler()), WithRouteMiddleware(guards...))
+}
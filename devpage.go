@@ -0,0 +1,163 @@
+package webgo
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"html/template"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WithDevMode enables development conveniences such as detailed error
+// pages. Never enable it in production: it reveals source code.
+func WithDevMode() Option {
+	return func(app *Application) {
+		app.devMode = true
+	}
+}
+
+func (app *Application) SetDevMode(enabled bool) {
+	app.devMode = enabled
+}
+
+func (app *Application) DevMode() bool {
+	return app.devMode
+}
+
+type SourceLine struct {
+	Number  int    `json:"number"`
+	Text    string `json:"text"`
+	Current bool   `json:"current"`
+}
+
+type StackFrame struct {
+	Function string       `json:"function"`
+	File     string       `json:"file"`
+	Line     int          `json:"line"`
+	Source   []SourceLine `json:"source,omitempty"`
+}
+
+type DevErrorPage struct {
+	Status  int               `json:"status"`
+	Error   string            `json:"error"`
+	Causes  []string          `json:"causes,omitempty"`
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Route   string            `json:"route,omitempty"`
+	Query   map[string]string `json:"query,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Stack   []StackFrame      `json:"stack,omitempty"`
+}
+
+var stackFileRe = regexp.MustCompile(`^\s+(.+\.go):(\d+)`)
+
+// parseStack turns a debug.Stack dump into frames, skipping the runtime
+// and the recovery machinery.
+func parseStack(stack []byte) []StackFrame {
+	var frames []StackFrame
+	lines := strings.Split(string(stack), "\n")
+	for i := 1; i+1 < len(lines); i++ {
+		m := stackFileRe.FindStringSubmatch(lines[i+1])
+		if m == nil || strings.HasPrefix(lines[i], "\t") {
+			continue
+		}
+		function := lines[i]
+		if p := strings.LastIndexByte(function, '('); p > 0 {
+			function = function[:p]
+		}
+		i++
+		if strings.HasPrefix(function, "runtime.") || strings.HasPrefix(function, "panic") ||
+			strings.HasSuffix(function, "debug.Stack") || strings.Contains(function, "(*Application).process.func") {
+			continue
+		}
+		line, _ := strconv.Atoi(m[2])
+		frames = append(frames, StackFrame{
+			Function: function,
+			File:     m[1],
+			Line:     line,
+			Source:   sourceSnippet(m[1], line, 5),
+		})
+	}
+	return frames
+}
+
+func sourceSnippet(file string, line, context int) []SourceLine {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var snippet []SourceLine
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan() && n <= line+context; n++ {
+		if n >= line-context {
+			snippet = append(snippet, SourceLine{n, scanner.Text(), n == line})
+		}
+	}
+	return snippet
+}
+
+var devErrorTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Status}} {{.Error}}</title>
+<style>
+body{font:14px sans-serif;margin:2em;color:#222}
+h1{color:#b00;font-size:20px;white-space:pre-wrap}
+pre{background:#f6f6f6;padding:8px;overflow:auto}
+.cur{background:#fdd;display:block}
+td{padding:2px 8px;vertical-align:top}
+</style></head><body>
+<h1>{{.Error}}</h1>
+{{range .Causes}}<p>caused by: {{.}}</p>{{end}}
+<p>{{.Method}} {{.Path}}{{if .Route}} &middot; route <code>{{.Route}}</code>{{end}}</p>
+{{range .Stack}}<h3><code>{{.Function}}</code></h3><p>{{.File}}:{{.Line}}</p>
+{{if .Source}}<pre>{{range .Source}}<span{{if .Current}} class="cur"{{end}}>{{printf "%4d" .Number}}  {{.Text}}
+</span>{{end}}</pre>{{end}}{{end}}
+<h2>Query</h2><table>{{range $k, $v := .Query}}<tr><td>{{$k}}</td><td>{{$v}}</td></tr>{{end}}</table>
+<h2>Headers</h2><table>{{range $k, $v := .Headers}}<tr><td>{{$k}}</td><td>{{$v}}</td></tr>{{end}}</table>
+</body></html>`))
+
+// devErrorResponse replaces a 5xx response carrying an error with a page
+// describing it, as HTML or as JSON depending on what the client accepts.
+func (app *Application) devErrorResponse(req *Request, resp *Response) *Response {
+	if !app.devMode || resp.err == nil || resp.Status < 500 {
+		return resp
+	}
+
+	page := &DevErrorPage{
+		Status:  resp.Status,
+		Error:   resp.err.Error(),
+		Method:  req.Method,
+		Path:    req.Path,
+		Route:   req.Route(),
+		Query:   req.Query,
+		Headers: make(map[string]string, len(req.Headers)),
+		Stack:   parseStack(resp.stack),
+	}
+	if resp.stack != nil {
+		page.Error = strings.SplitN(page.Error, "\n", 2)[0]
+	}
+	for err := errors.Unwrap(resp.err); err != nil; err = errors.Unwrap(err) {
+		page.Causes = append(page.Causes, err.Error())
+	}
+	for name, values := range req.Headers {
+		page.Headers[name] = strings.Join(values, ", ")
+	}
+
+	var out *Response
+	if NegotiateType(req, []string{"text/html", "application/json"}) == "application/json" {
+		out = JSON(resp.Status, page)
+	} else {
+		var buf bytes.Buffer
+		if err := devErrorTemplate.Execute(&buf, page); err != nil {
+			return resp
+		}
+		out = HTML(resp.Status, buf.String())
+	}
+	out.err = resp.err
+	out.timings = resp.timings
+	return out
+}
@@ -0,0 +1,89 @@
+package webgo
+
+import (
+	"context"
+	"time"
+)
+
+type DrainStatus struct {
+	Draining bool  `json:"draining"`
+	InFlight int64 `json:"in_flight"`
+	Drained  bool  `json:"drained"`
+}
+
+// InFlight returns the number of requests currently being handled.
+func (app *Application) InFlight() int64 {
+	return app.inFlight.Load()
+}
+
+// StartDrain puts the application in drain mode: readiness reports
+// draining and new requests are refused with 503 and Connection: close,
+// while requests already running are left to finish. Listeners stay open
+// so health and drain endpoints keep answering.
+func (app *Application) StartDrain() {
+	app.Health().SetDraining(true)
+	app.draining.Store(true)
+}
+
+func (app *Application) Draining() bool {
+	return app.draining.Load()
+}
+
+// WaitDrained blocks until no requests are in flight or ctx is done.
+func (app *Application) WaitDrained(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for app.InFlight() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+func (app *Application) DrainStatus() DrainStatus {
+	inFlight := app.InFlight()
+	return DrainStatus{
+		Draining: app.Draining(),
+		InFlight: inFlight,
+		Drained:  app.Draining() && inFlight == 0,
+	}
+}
+
+// EnableDrainEndpoint mounts GET path, reporting the drain status, and
+// POST path, which starts draining. The request asking for the status
+// doesn't count itself as in flight.
+func (app *Application) EnableDrainEndpoint(path string) {
+	app.exemptFromDrain(path)
+	app.Route("GET "+path, func(req *Request) *Response {
+		status := app.DrainStatus()
+		status.InFlight--
+		status.Drained = status.Draining && status.InFlight == 0
+		return JSON(200, status)
+	})
+	app.Route("POST "+path, func(req *Request) *Response {
+		app.StartDrain()
+		return JSON(202, app.DrainStatus())
+	})
+}
+
+func (app *Application) exemptFromDrain(paths ...string) {
+	if app.drainExempt == nil {
+		app.drainExempt = make(map[string]bool)
+	}
+	for _, path := range paths {
+		app.drainExempt[path] = true
+	}
+}
+
+func (app *Application) refuseWhileDraining(req *Request) *Response {
+	if !app.Draining() || app.drainExempt[req.Path] {
+		return nil
+	}
+	resp := ServiceUnavailable("draining")
+	resp.Headers.Set("Connection", "close")
+	resp.Headers.Set("Retry-After", "1")
+	return resp
+}
@@ -0,0 +1,107 @@
+package webgo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Err     error
+}
+
+func NewError(status int, code, message string) *Error {
+	return &Error{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// Errorf formats the message like fmt.Errorf, so a %w verb keeps the
+// wrapped error reachable through errors.Is and errors.As.
+func Errorf(status int, format string, args ...interface{}) *Error {
+	err := fmt.Errorf(format, args...)
+	return &Error{
+		Status:  status,
+		Message: err.Error(),
+		Err:     errors.Unwrap(err),
+	}
+}
+
+// WrapError wraps err with an HTTP status; zero means 500.
+func WrapError(status int, err error) *Error {
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	return &Error{
+		Status: status,
+		Err:    err,
+	}
+}
+
+func (e *Error) WithCode(code string) *Error {
+	e.Code = code
+	return e
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.status())
+	}
+	if e.Err != nil && !strings.Contains(msg, e.Err.Error()) {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// status is the Status to send, 500 for an Error built without one.
+func (e *Error) status() int {
+	if e.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.Status
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an *Error with the same status and code.
+// Zero fields in target act as wildcards.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return (t.Status == 0 || t.Status == e.Status) &&
+		(t.Code == "" || t.Code == e.Code)
+}
+
+func (e *Error) Problem() *Problem {
+	p := NewProblem(e.status(), e.Message)
+	if e.Code != "" {
+		p.With("code", e.Code)
+	}
+	return p
+}
+
+type ErrorProcessFunc func(*Request) (*Response, error)
+
+func (app *Application) RouteE(pattern string, procFunc ErrorProcessFunc, opts ...RouteOption) *Processor {
+	return app.Route(pattern, func(req *Request) *Response {
+		resp, err := procFunc(req)
+		if err != nil {
+			return app.HandleError(req, err)
+		}
+		if resp == nil {
+			return NoContent()
+		}
+		return resp
+	}, opts...)
+}
@@ -0,0 +1,37 @@
+package webgo
+
+import (
+	"context"
+	"net"
+	"net/http/fcgi"
+)
+
+// RunFCGI serves the application over FastCGI on l. A nil listener serves
+// on stdin, which is how front ends that spawn the process pass the socket.
+func (app *Application) RunFCGI(l net.Listener) error {
+	if err := app.runStartHooks(); err != nil {
+		if l != nil {
+			l.Close()
+		}
+		return err
+	}
+	closed := make(chan struct{})
+	if l != nil {
+		app.setAddr(l.Addr())
+		app.mu.Lock()
+		app.shutdownFuncs = append(app.shutdownFuncs, func(context.Context) error {
+			close(closed)
+			return l.Close()
+		})
+		app.mu.Unlock()
+	}
+
+	err := fcgi.Serve(l, app)
+	select {
+	case <-closed:
+		<-app.shutdownDone
+		return nil
+	default:
+		return err
+	}
+}
@@ -0,0 +1,149 @@
+package webgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// FetchOptions describes an outbound request. At most one of Body, JSON
+// and Form should be set.
+type FetchOptions struct {
+	Header http.Header
+	Query  url.Values
+	Body   []byte
+	JSON   interface{}
+	Form   url.Values
+
+	// Timeout bounds the request including reading the body; it defaults
+	// to 30 seconds.
+	Timeout time.Duration
+	Client  *http.Client
+
+	// Guard, if set and Client is not, refuses connections to internal
+	// addresses; set it when rawURL comes from user input.
+	Guard *SSRFGuard
+	// MaxBody limits the response body in bytes; a longer body fails the
+	// fetch. Zero means no limit, or 10 MB when Guard is set.
+	MaxBody int64
+}
+
+// Fetch performs an HTTP request and returns the buffered response. The
+// trace context in ctx, if any, is propagated. Non-2xx responses are not
+// errors; check Status.
+func Fetch(ctx context.Context, method, rawURL string, opts *FetchOptions) (*Response, error) {
+	if opts == nil {
+		opts = &FetchOptions{}
+	}
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var body io.Reader
+	contentType := ""
+	switch {
+	case opts.JSON != nil:
+		b, err := json.Marshal(opts.JSON)
+		if err != nil {
+			return nil, err
+		}
+		body, contentType = bytes.NewReader(b), "application/json"
+	case opts.Form != nil:
+		body, contentType = strings.NewReader(opts.Form.Encode()), "application/x-www-form-urlencoded"
+	case opts.Body != nil:
+		body = bytes.NewReader(opts.Body)
+	}
+
+	if len(opts.Query) > 0 {
+		sep := "?"
+		if strings.Contains(rawURL, "?") {
+			sep = "&"
+		}
+		rawURL += sep + opts.Query.Encode()
+	}
+	r, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range opts.Header {
+		r.Header[name] = values
+	}
+	if contentType != "" && r.Header.Get("Content-Type") == "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	if opts.JSON != nil && r.Header.Get("Accept") == "" {
+		r.Header.Set("Accept", "application/json")
+	}
+	InjectTrace(ctx, r.Header)
+
+	client := opts.Client
+	switch {
+	case client != nil:
+	case opts.Guard != nil:
+		client = opts.Guard.Client()
+	default:
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	maxBody := opts.MaxBody
+	if maxBody == 0 && opts.Guard != nil {
+		maxBody = 10 << 20
+	}
+	if maxBody <= 0 {
+		return parseResponse(resp)
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(resp.Body, maxBody+1), resp.Body}
+	res, err := parseResponse(resp)
+	if err == nil && int64(len(res.Body)) > maxBody {
+		return nil, fmt.Errorf("%s %s: response body exceeds %d bytes", method, rawURL, maxBody)
+	}
+	return res, err
+}
+
+func FetchGet(ctx context.Context, rawURL string) (*Response, error) {
+	return Fetch(ctx, "GET", rawURL, nil)
+}
+
+// FetchJSON sends in (if not nil) as JSON and decodes a 2xx JSON reply
+// into out (if not nil). Other statuses yield a 502 *Error, so a handler
+// returning it reports a failed dependency without exposing the reply;
+// the upstream status and body are in its wrapped error and in resp.
+func FetchJSON(ctx context.Context, method, rawURL string, in, out interface{}) (*Response, error) {
+	opts := &FetchOptions{JSON: in, Header: http.Header{"Accept": {"application/json"}}}
+	resp, err := Fetch(ctx, method, rawURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status < 200 || resp.Status > 299 {
+		msg := string(resp.Body)
+		if len(msg) > 200 {
+			msg = msg[:200]
+		}
+		err := fmt.Errorf("%s %s: %d: %s", method, rawURL, resp.Status, msg)
+		return resp, WrapError(http.StatusBadGateway, err).WithCode("upstream_error")
+	}
+	if out != nil && len(resp.Body) > 0 {
+		return resp, resp.DecodeJSON(out)
+	}
+	return resp, nil
+}
+
+// DecodeJSON unmarshals the buffered body into v.
+func (resp *Response) DecodeJSON(v interface{}) error {
+	return json.Unmarshal(resp.Body, v)
+}
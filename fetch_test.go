@@ -0,0 +1,55 @@
+package webgo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchMaxBody(t *testing.T) {
+	big := strings.Repeat("x", 11<<20)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/big" {
+			w.Write([]byte(big))
+			return
+		}
+		w.Write([]byte("0123456789"))
+	}))
+	defer upstream.Close()
+
+	guard, err := NewSSRFGuard("127.0.0.1", "::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name    string
+		path    string
+		opts    FetchOptions
+		wantErr bool
+	}{
+		{"NoLimit", "/big", FetchOptions{}, false},
+		{"UnderLimit", "/", FetchOptions{MaxBody: 10}, false},
+		{"OverLimit", "/", FetchOptions{MaxBody: 9}, true},
+		{"GuardDefault", "/big", FetchOptions{Guard: guard}, true},
+		{"GuardExplicit", "/big", FetchOptions{Guard: guard, MaxBody: 12 << 20}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			resp, err := Fetch(context.Background(), "GET", upstream.URL+tt.path, &opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Fetch returned %d bytes, want an error", len(resp.Body))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			if resp.Status != 200 {
+				t.Errorf("status = %d, want 200", resp.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package webgo
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// File responds with the contents of the file at path. Range, If-Range and
+// conditional requests are handled when the response is written, by
+// http.ServeContent, which hands the copy to sendfile on plain HTTP/1.x
+// connections instead of reading the file through userspace buffers.
+func File(path string) *Response {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NotFound("")
+		}
+		if os.IsPermission(err) {
+			return Forbidden("")
+		}
+		return InternalError(err)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return InternalError(err)
+	}
+	if stat.IsDir() {
+		f.Close()
+		return NotFound("")
+	}
+
+	resp := Respond(200, nil)
+	resp.BodyReader = f
+	resp.name = filepath.Base(path)
+	resp.modTime = stat.ModTime()
+	return resp
+}
+
+// Content responds with a seekable reader, so clients can request ranges
+// of it. The name is only used to guess the Content-Type.
+func Content(name string, modTime time.Time, content io.ReadSeeker) *Response {
+	resp := Respond(200, nil)
+	resp.BodyReader = content
+	resp.name = name
+	resp.modTime = modTime
+	return resp
+}
+
+// Static serves the files below dir under prefix, for GET and HEAD. Files
+// are sent with File, so the kernel copies them straight to the socket
+// where it can (sendfile), and ranges and conditional requests work. A
+// directory serves its index.html; there are no listings, and names
+// starting with a dot are not served.
+func (app *Application) Static(prefix, dir string) *Processor {
+	prefix = strings.TrimRight(prefix, "/")
+	return app.Route("(?:GET|HEAD) "+regexp.QuoteMeta(prefix)+"(/.*)?", func(req *Request) *Response {
+		name := path.Clean("/" + req.Arguments[0])
+		for _, part := range strings.Split(name, "/") {
+			if strings.HasPrefix(part, ".") {
+				return NotFound("")
+			}
+		}
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if stat, err := os.Stat(full); err == nil && stat.IsDir() {
+			full = filepath.Join(full, "index.html")
+		}
+		return File(full)
+	})
+}
@@ -0,0 +1,63 @@
+package webgo
+
+const flashKey = "_flash"
+
+// Flash is a one-shot message kept in the session until it is read,
+// usually on the page a POST handler redirects to.
+type Flash struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// Flash queues a message of the given kind ("success", "error"...) for
+// the next page rendered for this client.
+func (sess *Session) Flash(kind, message string) {
+	var flashes []Flash
+	sess.Decode(flashKey, &flashes)
+	sess.Set(flashKey, append(flashes, Flash{kind, message}))
+}
+
+// Flashes returns and removes the queued messages, limited to kinds if
+// any are given; messages of other kinds stay queued.
+func (sess *Session) Flashes(kinds ...string) []Flash {
+	var flashes []Flash
+	if !sess.Decode(flashKey, &flashes) {
+		return nil
+	}
+	if len(kinds) == 0 {
+		sess.Delete(flashKey)
+		return flashes
+	}
+
+	var taken, kept []Flash
+	for _, f := range flashes {
+		if containsString(kinds, f.Kind) {
+			taken = append(taken, f)
+		} else {
+			kept = append(kept, f)
+		}
+	}
+	if len(kept) == 0 {
+		sess.Delete(flashKey)
+	} else if len(taken) > 0 {
+		sess.Set(flashKey, kept)
+	}
+	return taken
+}
+
+// InjectFlashes is an Injector exposing the queued messages to templates
+// as .Flashes, consuming them:
+//
+//	renderer.Inject(webgo.InjectFlashes)
+func InjectFlashes(req *Request, data map[string]interface{}) {
+	data["Flashes"] = req.Session().Flashes()
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
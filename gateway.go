@@ -0,0 +1,59 @@
+package webgo
+
+import (
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"strings"
+)
+
+// GatewayOptions configures MountGateway.
+type GatewayOptions struct {
+	// Metadata returns extra gRPC metadata for a request. It is sent as
+	// Grpc-Metadata-* headers, which gateways forward to the backend by
+	// default.
+	Metadata func(req *Request) map[string]string
+
+	// UserKey is the metadata key carrying the authenticated user's ID,
+	// "x-user-id" by default. Clients can't send any metadata themselves.
+	UserKey string
+}
+
+// MountGateway mounts gw, typically a grpc-gateway runtime.ServeMux with
+// generated handlers registered on it, on prefix. The gateway sees the
+// full request path, so prefix should be the common start of the paths in
+// the proto HTTP annotations, like "/v1". Requests go through the app's
+// middleware and mws first, then reach the gateway with the trace context
+// and the logged-in user forwarded as gRPC metadata.
+func (app *Application) MountGateway(prefix string, gw http.Handler, opts GatewayOptions, mws ...Middleware) {
+	userKey := opts.UserKey
+	if userKey == "" {
+		userKey = "x-user-id"
+	}
+	userHeader := gatewayMetadataHeader(userKey)
+	serve := WrapHandler(gw)
+
+	prefix = strings.TrimRight(prefix, "/")
+	app.Route(".* "+regexp.QuoteMeta(prefix)+"(/.*)?", func(req *Request) *Response {
+		// metadata the gateway is told to trust comes from us only
+		for name := range req.Headers {
+			if strings.HasPrefix(textproto.CanonicalMIMEHeaderKey(name), "Grpc-Metadata-") {
+				delete(req.Headers, name)
+			}
+		}
+		if user := req.User(); user != nil {
+			req.Headers.Set(userHeader, user.UserID())
+		}
+		InjectTrace(req.Context(), req.Headers)
+		if opts.Metadata != nil {
+			for key, value := range opts.Metadata(req) {
+				req.Headers.Set(gatewayMetadataHeader(key), value)
+			}
+		}
+		return serve(req)
+	}, WithRouteMiddleware(mws...))
+}
+
+func gatewayMetadataHeader(key string) string {
+	return textproto.CanonicalMIMEHeaderKey("Grpc-Metadata-" + strings.ToLower(key))
+}
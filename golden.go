@@ -0,0 +1,179 @@
+package webgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TestingT is the subset of *testing.T used by the golden-file helpers.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// GoldenNormalizer rewrites volatile parts of a serialized response, such
+// as timestamps or generated IDs, before it is compared.
+type GoldenNormalizer func([]byte) []byte
+
+func ReplacePattern(pattern, repl string) GoldenNormalizer {
+	re := regexp.MustCompile(pattern)
+	return func(b []byte) []byte {
+		return re.ReplaceAll(b, []byte(repl))
+	}
+}
+
+var (
+	NormalizeTimestamps = ReplacePattern(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`, "<timestamp>")
+	NormalizeUUIDs      = ReplacePattern(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`, "<uuid>")
+)
+
+// Golden compares responses against files in Dir. Files are (re)written
+// instead of compared when Update is set, which NewGolden does when the
+// WEBGO_UPDATE_GOLDEN environment variable is non-empty.
+type Golden struct {
+	Dir           string
+	Update        bool
+	IgnoreHeaders []string
+	Normalizers   []GoldenNormalizer
+}
+
+func NewGolden(dir string, normalizers ...GoldenNormalizer) *Golden {
+	return &Golden{
+		Dir:           dir,
+		Update:        os.Getenv("WEBGO_UPDATE_GOLDEN") != "",
+		IgnoreHeaders: []string{"Date"},
+		Normalizers:   normalizers,
+	}
+}
+
+// Assert serializes resp and compares it with the golden file
+// Dir/name.golden, reporting a line diff through t on mismatch.
+func (g *Golden) Assert(t TestingT, name string, resp *Response) {
+	t.Helper()
+	got, err := g.serialize(resp)
+	if err != nil {
+		t.Fatalf("golden %s: %v", name, err)
+		return
+	}
+
+	path := filepath.Join(g.Dir, name+".golden")
+	if g.Update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("golden %s: %v", name, err)
+			return
+		}
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("golden %s: %v", name, err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden %s: %s does not exist; run with WEBGO_UPDATE_GOLDEN=1 to create it", name, path)
+		return
+	} else if err != nil {
+		t.Fatalf("golden %s: %v", name, err)
+		return
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("golden %s: response differs from %s:\n%s", name, path, lineDiff(string(want), string(got)))
+	}
+}
+
+func (g *Golden) serialize(resp *Response) ([]byte, error) {
+	out, err := SerializeResponse(resp, g.IgnoreHeaders...)
+	if err != nil {
+		return nil, err
+	}
+	for _, normalize := range g.Normalizers {
+		out = normalize(out)
+	}
+	return out, nil
+}
+
+// SerializeResponse renders resp in a stable, diffable form: the status
+// line, headers sorted by canonical name, a blank line and the body. JSON
+// bodies are indented. A BodyReader is drained into Body.
+func SerializeResponse(resp *Response, ignoreHeaders ...string) ([]byte, error) {
+	if resp.BodyReader != nil {
+		body, err := ioutil.ReadAll(resp.BodyReader)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body, resp.BodyReader = body, nil
+	}
+
+	ignored := make(map[string]bool)
+	for _, name := range ignoreHeaders {
+		ignored[http.CanonicalHeaderKey(name)] = true
+	}
+	var names []string
+	for name := range resp.Headers {
+		if !ignored[http.CanonicalHeaderKey(name)] {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return http.CanonicalHeaderKey(names[i]) < http.CanonicalHeaderKey(names[j])
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d %s\n", resp.Status, http.StatusText(resp.Status))
+	for _, name := range names {
+		for _, value := range resp.Headers[name] {
+			fmt.Fprintf(&buf, "%s: %s\n", http.CanonicalHeaderKey(name), value)
+		}
+	}
+	buf.WriteByte('\n')
+
+	body := resp.Body
+	if strings.Contains(resp.Headers.Get("Content-Type"), "json") {
+		var indented bytes.Buffer
+		if json.Indent(&indented, body, "", "  ") == nil {
+			body = indented.Bytes()
+		}
+	}
+	buf.Write(body)
+	if len(body) > 0 && body[len(body)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// lineDiff is a minimal diff marking lines that differ at the same
+// position; it is enough to spot what changed in a snapshot.
+func lineDiff(want, got string) string {
+	wl := strings.Split(want, "\n")
+	gl := strings.Split(got, "\n")
+	var b strings.Builder
+	for i := 0; i < len(wl) || i < len(gl); i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		switch {
+		case w == g:
+			fmt.Fprintf(&b, "  %s\n", w)
+		case i >= len(wl):
+			fmt.Fprintf(&b, "+ %s\n", g)
+		case i >= len(gl):
+			fmt.Fprintf(&b, "- %s\n", w)
+		default:
+			fmt.Fprintf(&b, "- %s\n+ %s\n", w, g)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,385 @@
+package webgo
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+)
+
+// GraphQLParams is one GraphQL request, as sent by GET query parameters or
+// a POST body.
+type GraphQLParams struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+type GraphQLResult struct {
+	Data       interface{}            `json:"data,omitempty"`
+	Errors     []GraphQLError         `json:"errors,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLExecutor runs a request against a schema. It adapts whichever
+// GraphQL library the application uses, for graphql-go something like:
+//
+//	func(ctx context.Context, p webgo.GraphQLParams) *webgo.GraphQLResult {
+//		r := graphql.Do(graphql.Params{Schema: schema, Context: ctx,
+//			RequestString: p.Query, VariableValues: p.Variables,
+//			OperationName: p.OperationName})
+//		...
+//	}
+//
+// The request is reachable from ctx through GraphQLRequest.
+type GraphQLExecutor func(ctx context.Context, params GraphQLParams) *GraphQLResult
+
+// PersistedQueryStore maps SHA-256 hashes to query documents for
+// automatic persisted queries.
+type PersistedQueryStore interface {
+	Get(hash string) (string, bool)
+	Put(hash, query string)
+}
+
+// GraphQL serves a GraphQL endpoint over GET and POST, following the
+// GraphQL-over-HTTP conventions. Mutations are refused over GET.
+type GraphQL struct {
+	Execute GraphQLExecutor
+
+	// PersistedQueries, if set, enables Apollo-style automatic persisted
+	// queries: clients send a query's hash and only send the full query
+	// when the server doesn't know it yet.
+	PersistedQueries PersistedQueryStore
+
+	// PersistedOnly rejects queries not already in PersistedQueries, so
+	// only the operations registered ahead of time can run.
+	PersistedOnly bool
+
+	// GraphiQL serves the GraphiQL IDE to browsers opening the endpoint.
+	// It is always served in development mode.
+	GraphiQL bool
+}
+
+func NewGraphQL(execute GraphQLExecutor) *GraphQL {
+	return &GraphQL{Execute: execute}
+}
+
+// ServeGraphQL routes GET and POST requests on path to g.
+func (app *Application) ServeGraphQL(path string, g *GraphQL) *Processor {
+	return app.Route("(GET|POST) "+path, g.Process, WithRouteDoc(RouteDoc{Hidden: true}))
+}
+
+type graphQLRequestKey struct{}
+
+// GraphQLRequest returns the request a GraphQLExecutor is serving.
+func GraphQLRequest(ctx context.Context) *Request {
+	req, _ := ctx.Value(graphQLRequestKey{}).(*Request)
+	return req
+}
+
+func (g *GraphQL) Process(req *Request) *Response {
+	if req.Method == "GET" && g.wantsGraphiQL(req) {
+		return HTML(200, graphiQLPage)
+	}
+
+	params, err := graphQLParams(req)
+	if err != nil {
+		return graphQLErrorResponse(400, err.Error(), "")
+	}
+	if resp := g.resolvePersisted(&params); resp != nil {
+		return resp
+	}
+	if strings.TrimSpace(params.Query) == "" {
+		return graphQLErrorResponse(400, "missing query", "")
+	}
+	// an operation the scan can't make out might be a mutation
+	if op := graphQLOperationType(params.Query, params.OperationName); req.Method == "GET" && op != "query" {
+		resp := graphQLErrorResponse(405, "only queries can be sent with GET", "")
+		resp.Headers.Set("Allow", "POST")
+		return resp
+	}
+
+	ctx := context.WithValue(req.Context(), graphQLRequestKey{}, req)
+	result := g.Execute(ctx, params)
+	if result == nil {
+		result = &GraphQLResult{}
+	}
+	return JSON(200, result)
+}
+
+func (g *GraphQL) wantsGraphiQL(req *Request) bool {
+	if !g.GraphiQL && (req.app == nil || !req.app.devMode) {
+		return false
+	}
+	if _, ok := req.Query["query"]; ok {
+		return false
+	}
+	return strings.Contains(req.Headers.Get("Accept"), "text/html")
+}
+
+func graphQLParams(req *Request) (GraphQLParams, error) {
+	var params GraphQLParams
+	if req.Method == "POST" {
+		body, err := req.bodyBytes()
+		if err != nil {
+			return params, fmt.Errorf("reading request body: %v", err)
+		}
+		mediaType, _, _ := mime.ParseMediaType(req.Headers.Get("Content-Type"))
+		switch mediaType {
+		case "application/graphql":
+			params.Query = string(body)
+		case "application/json", "":
+			if err := json.Unmarshal(body, &params); err != nil {
+				return params, fmt.Errorf("invalid request body: %v", err)
+			}
+		default:
+			return params, fmt.Errorf("unsupported content type %q", mediaType)
+		}
+		return params, nil
+	}
+
+	params.Query = req.Query["query"]
+	params.OperationName = req.Query["operationName"]
+	for name, dst := range map[string]*map[string]interface{}{
+		"variables":  &params.Variables,
+		"extensions": &params.Extensions,
+	} {
+		if v := req.Query[name]; v != "" {
+			if err := json.Unmarshal([]byte(v), dst); err != nil {
+				return params, fmt.Errorf("invalid %s: %v", name, err)
+			}
+		}
+	}
+	return params, nil
+}
+
+// resolvePersisted fills in or records the query of a request carrying a
+// persistedQuery extension. It returns a response if the request can't
+// go on.
+func (g *GraphQL) resolvePersisted(params *GraphQLParams) *Response {
+	ext, _ := params.Extensions["persistedQuery"].(map[string]interface{})
+	if g.PersistedQueries == nil {
+		if ext != nil {
+			return graphQLErrorResponse(200, "PersistedQueryNotSupported", "PERSISTED_QUERY_NOT_SUPPORTED")
+		}
+		return nil
+	}
+
+	hash, _ := ext["sha256Hash"].(string)
+	if hash == "" {
+		if g.PersistedOnly {
+			return graphQLErrorResponse(400, "only persisted queries are allowed", "PERSISTED_QUERY_REQUIRED")
+		}
+		return nil
+	}
+	hash = strings.ToLower(hash)
+
+	if params.Query == "" {
+		query, ok := g.PersistedQueries.Get(hash)
+		if !ok {
+			// clients retry with the full query on this exact message
+			return graphQLErrorResponse(200, "PersistedQueryNotFound", "PERSISTED_QUERY_NOT_FOUND")
+		}
+		params.Query = query
+		return nil
+	}
+
+	sum := sha256.Sum256([]byte(params.Query))
+	if hex.EncodeToString(sum[:]) != hash {
+		return graphQLErrorResponse(400, "provided sha does not match query", "")
+	}
+	if g.PersistedOnly {
+		if _, ok := g.PersistedQueries.Get(hash); !ok {
+			return graphQLErrorResponse(400, "only persisted queries are allowed", "PERSISTED_QUERY_REQUIRED")
+		}
+		return nil
+	}
+	g.PersistedQueries.Put(hash, params.Query)
+	return nil
+}
+
+func graphQLErrorResponse(status int, message, code string) *Response {
+	e := GraphQLError{Message: message}
+	if code != "" {
+		e.Extensions = map[string]interface{}{"code": code}
+	}
+	return JSON(status, GraphQLResult{Errors: []GraphQLError{e}})
+}
+
+// graphQLOperationType returns "query", "mutation" or "subscription" for
+// the operation of document that would run, or "" if there is none. It
+// only scans top-level tokens; the executor does the real validation.
+func graphQLOperationType(document, operationName string) string {
+	var ops []string
+	var names []string
+	depth := 0
+	expectOp := true
+	for i := 0; i < len(document); {
+		c := document[i]
+		switch {
+		case c == '#':
+			for i < len(document) && document[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '"':
+			i = skipGraphQLString(document, i)
+			continue
+		case c == '{':
+			if depth == 0 && expectOp {
+				// anonymous query shorthand
+				ops, names = append(ops, "query"), append(names, "")
+			}
+			depth++
+			expectOp = false
+		case c == '}':
+			depth--
+			if depth == 0 {
+				expectOp = true
+			}
+		case depth == 0 && isGraphQLNameStart(c):
+			start := i
+			for i < len(document) && isGraphQLNameChar(document[i]) {
+				i++
+			}
+			word := document[start:i]
+			if expectOp {
+				switch word {
+				case "query", "mutation", "subscription":
+					ops = append(ops, word)
+					names = append(names, graphQLNextName(document[i:]))
+				}
+				expectOp = false
+			}
+			continue
+		}
+		i++
+	}
+
+	for i, op := range ops {
+		if operationName == "" || names[i] == operationName {
+			if operationName == "" && len(ops) > 1 {
+				return ""
+			}
+			return op
+		}
+	}
+	return ""
+}
+
+func graphQLNextName(s string) string {
+	s = strings.TrimLeft(s, " \t\r\n,")
+	i := 0
+	for i < len(s) && isGraphQLNameChar(s[i]) {
+		i++
+	}
+	return s[:i]
+}
+
+func skipGraphQLString(s string, i int) int {
+	if strings.HasPrefix(s[i:], `"""`) {
+		if end := strings.Index(s[i+3:], `"""`); end >= 0 {
+			return i + 3 + end + 3
+		}
+		return len(s)
+	}
+	for i++; i < len(s) && s[i] != '"' && s[i] != '\n'; i++ {
+		if s[i] == '\\' {
+			i++
+		}
+	}
+	return i + 1
+}
+
+func isGraphQLNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isGraphQLNameChar(c byte) bool {
+	return isGraphQLNameStart(c) || (c >= '0' && c <= '9')
+}
+
+// MemoryPersistedQueries is a PersistedQueryStore kept in memory. Any
+// client can register queries, so it holds at most maxEntries of them,
+// forgetting the least recently used; clients resend those when asked.
+type MemoryPersistedQueries struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	queries    map[string]*list.Element
+}
+
+type persistedQuery struct {
+	hash, query string
+}
+
+// NewMemoryPersistedQueries returns a store of at most maxEntries
+// queries. Zero means no limit, for a set registered ahead of time and
+// served with PersistedOnly.
+func NewMemoryPersistedQueries(maxEntries int) *MemoryPersistedQueries {
+	return &MemoryPersistedQueries{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		queries:    make(map[string]*list.Element),
+	}
+}
+
+func (m *MemoryPersistedQueries) Get(hash string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.queries[hash]
+	if !ok {
+		return "", false
+	}
+	m.order.MoveToFront(el)
+	return el.Value.(*persistedQuery).query, true
+}
+
+func (m *MemoryPersistedQueries) Put(hash, query string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.queries[hash]; ok {
+		el.Value.(*persistedQuery).query = query
+		m.order.MoveToFront(el)
+		return
+	}
+	m.queries[hash] = m.order.PushFront(&persistedQuery{hash, query})
+	if m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.queries, oldest.Value.(*persistedQuery).hash)
+	}
+}
+
+const graphiQLPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>GraphiQL</title>
+<style>body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+<link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+</head>
+<body>
+<div id="graphiql"></div>
+<script src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+<script src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+<script src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+<script>
+ReactDOM.createRoot(document.getElementById("graphiql")).render(
+  React.createElement(GraphiQL, {fetcher: GraphiQL.createFetcher({url: location.pathname})}));
+</script>
+</body>
+</html>
+`
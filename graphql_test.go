@@ -0,0 +1,67 @@
+package webgo
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestGraphQLGetOnlyRunsQueries(t *testing.T) {
+	app := NewApplication()
+	executed := false
+	app.ServeGraphQL("/graphql", NewGraphQL(func(ctx context.Context, params GraphQLParams) *GraphQLResult {
+		executed = true
+		return &GraphQLResult{Data: map[string]interface{}{"ok": true}}
+	}))
+
+	for _, tt := range []struct {
+		name, query, operation string
+		status                 int
+	}{
+		{"Shorthand", "{ me { id } }", "", 200},
+		{"NamedQuery", "query Me { me { id } }", "", 200},
+		{"Mutation", "mutation { logout }", "", 405},
+		{"SelectedMutation", "query A { me } mutation B { logout }", "B", 405},
+		{"UnknownOperation", "query A { me }", "B", 405},
+		{"Ambiguous", "query A { me } mutation B { logout }", "", 405},
+		{"Unparsable", "fragment F on User { id }", "", 405},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			executed = false
+			q := url.Values{"query": {tt.query}}
+			if tt.operation != "" {
+				q.Set("operationName", tt.operation)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest("GET", "/graphql?"+q.Encode(), nil))
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if executed != (tt.status == 200) {
+				t.Errorf("executed = %v", executed)
+			}
+		})
+	}
+}
+
+func TestMemoryPersistedQueriesLimit(t *testing.T) {
+	m := NewMemoryPersistedQueries(2)
+	m.Put("a", "{ a }")
+	m.Put("b", "{ b }")
+	m.Get("a")
+	m.Put("c", "{ c }")
+
+	for _, tt := range []struct {
+		hash string
+		kept bool
+	}{
+		{"a", true},
+		{"b", false},
+		{"c", true},
+	} {
+		if _, ok := m.Get(tt.hash); ok != tt.kept {
+			t.Errorf("Get(%q) found = %v, want %v", tt.hash, ok, tt.kept)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package webgo
+
+import (
+	"net/http"
+)
+
+// EnableH2C lets plaintext listeners accept HTTP/2 with prior knowledge
+// (h2c) alongside HTTP/1, for gateways and load balancers that speak
+// HTTP/2 without TLS.
+func (app *Application) EnableH2C() {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+	app.httpServer.Protocols = protocols
+}
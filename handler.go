@@ -0,0 +1,79 @@
+package webgo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// WrapHandler adapts a net/http handler into a ProcessFunc. The handler
+// writes directly to the connection; headers already set on the returned
+// Response are sent along.
+func WrapHandler(h http.Handler) ProcessFunc {
+	return func(req *Request) *Response {
+		resp := Respond(200, nil)
+		resp.serveHTTP = func(w http.ResponseWriter) {
+			r := req.raw.WithContext(req.Context())
+			if !req.streamed {
+				r.Body = ioutil.NopCloser(req.BodyReader())
+			}
+			h.ServeHTTP(w, r)
+		}
+		return resp
+	}
+}
+
+// Mount routes every method on prefix and everything below it to h. The
+// handler sees the full request path.
+func (app *Application) Mount(prefix string, h http.Handler, mws ...Middleware) {
+	prefix = strings.TrimRight(prefix, "/")
+	app.Route(".* "+regexp.QuoteMeta(prefix)+"(/.*)?", WrapHandler(h), WithRouteMiddleware(mws...))
+}
+
+// MountWebDAV mounts a WebDAV handler, such as a golang.org/x/net/webdav
+// Handler with its Prefix set to prefix, behind mws. Uploads are streamed
+// to the handler rather than buffered, and it writes its own multi-status
+// and lock responses.
+func (app *Application) MountWebDAV(prefix string, h http.Handler, mws ...Middleware) {
+	prefix = strings.TrimRight(prefix, "/")
+	app.Route(".* "+regexp.QuoteMeta(prefix)+"(/.*)?", WrapHandler(h), WithStreamBody(), WithRouteMiddleware(mws...))
+}
+
+// bufferedResponseWriter collects a response in memory, for serving
+// requests that don't come from a real connection.
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if w.status == 0 && status >= 200 {
+		w.status = status
+	}
+}
+
+func (w *bufferedResponseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(200)
+	return w.body.Write(p)
+}
+
+// serveBuffered runs r through the application and returns what it wrote.
+func (app *Application) serveBuffered(r *http.Request) *Response {
+	w := &bufferedResponseWriter{header: make(http.Header)}
+	app.ServeHTTP(w, r)
+	if w.status == 0 {
+		w.status = 200
+	}
+	return &Response{
+		Status:  w.status,
+		Headers: w.header,
+		Body:    w.body.Bytes(),
+	}
+}
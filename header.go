@@ -0,0 +1,36 @@
+package webgo
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Header values the response constructors share instead of allocating
+// them for every response, set by direct assignment of canonical keys.
+// Their capacity is their length, so an Add copies rather than appends
+// in place, and Set replaces them; nothing writes to them.
+var (
+	contentTypeJSON  = []string{"application/json; charset=utf-8"}
+	contentTypeJSONP = []string{"text/javascript; charset=utf-8"}
+	contentTypeText  = []string{"text/plain; charset=utf-8"}
+	contentTypeHTML  = []string{"text/html; charset=utf-8"}
+	noSniff          = []string{"nosniff"}
+)
+
+// copyHeaders adds src to dst a key at a time rather than a value at a
+// time. Keys set through http.Header's methods are canonical already, so
+// they are not canonicalized again; the slices are shared, capped so a
+// later Add to dst copies instead of writing into src's.
+func copyHeaders(dst, src http.Header) {
+	for name, values := range src {
+		if existing, ok := dst[name]; ok {
+			dst[name] = append(existing, values...)
+		} else {
+			dst[name] = values[:len(values):len(values)]
+		}
+	}
+}
+
+func setContentLength(h http.Header, n int) {
+	h["Content-Length"] = []string{strconv.Itoa(n)}
+}
@@ -0,0 +1,160 @@
+package webgo
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type HealthCheck func(ctx context.Context) error
+
+type healthCheck struct {
+	name    string
+	timeout time.Duration
+	check   HealthCheck
+}
+
+type CheckResult struct {
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration_ms"`
+	Error    string  `json:"error,omitempty"`
+}
+
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type Health struct {
+	mu       sync.RWMutex
+	checks   []healthCheck
+	draining atomic.Bool
+}
+
+// Health returns the application's health registry.
+func (app *Application) Health() *Health {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if app.health == nil {
+		app.health = &Health{}
+	}
+	return app.health
+}
+
+// EnableHealthEndpoints mounts GET /healthz (liveness) and GET /readyz
+// (readiness, running every registered check).
+func (app *Application) EnableHealthEndpoints() {
+	h := app.Health()
+	app.exemptFromDrain("/healthz", "/readyz")
+	app.Route("GET /healthz", h.Live)
+	app.Route("GET /readyz", h.Ready)
+}
+
+// AddCheck registers a readiness check. A check that takes longer than
+// timeout fails.
+func (h *Health) AddCheck(name string, timeout time.Duration, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, healthCheck{name, timeout, check})
+}
+
+func (h *Health) SetDraining(draining bool) {
+	h.draining.Store(draining)
+}
+
+func (h *Health) Draining() bool {
+	return h.draining.Load()
+}
+
+// Run executes all checks concurrently and reports their results.
+func (h *Health) Run(ctx context.Context) *HealthReport {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	report := &HealthReport{
+		Status: "ok",
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+	if h.Draining() {
+		report.Status = "draining"
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c healthCheck) {
+			defer wg.Done()
+			result := runCheck(ctx, c)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = result
+			if result.Status != "ok" && report.Status == "ok" {
+				report.Status = "fail"
+			}
+		}(c)
+	}
+	wg.Wait()
+	return report
+}
+
+func runCheck(ctx context.Context, c healthCheck) CheckResult {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Status:   "ok",
+		Duration: float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if err != nil {
+		result.Status = "fail"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (h *Health) Healthy(ctx context.Context) bool {
+	return h.Run(ctx).Status == "ok"
+}
+
+// checksPass reports whether every check passed, draining or not.
+func (r *HealthReport) checksPass() bool {
+	for _, c := range r.Checks {
+		if c.Status != "ok" {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *Health) Live(req *Request) *Response {
+	return JSON(200, &HealthReport{Status: "ok"})
+}
+
+func (h *Health) Ready(req *Request) *Response {
+	report := h.Run(req.Context())
+	status := 200
+	if report.Status != "ok" {
+		status = 503
+	}
+	resp := JSON(status, report)
+	resp.Headers.Set("Cache-Control", "no-store")
+	return resp
+}
@@ -0,0 +1,35 @@
+package webgo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHealthChecksPassWhileDraining(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		draining bool
+		err      error
+		healthy  bool
+		pass     bool
+	}{
+		{"Serving", false, nil, true, true},
+		{"Draining", true, nil, false, true},
+		{"Failing", false, errors.New("down"), false, false},
+		{"DrainingAndFailing", true, errors.New("down"), false, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewApplication().Health()
+			err := tt.err
+			h.AddCheck("db", 0, func(ctx context.Context) error { return err })
+			h.SetDraining(tt.draining)
+			if got := h.Healthy(context.Background()); got != tt.healthy {
+				t.Errorf("Healthy() = %v, want %v", got, tt.healthy)
+			}
+			if got := h.Run(context.Background()).checksPass(); got != tt.pass {
+				t.Errorf("checksPass() = %v, want %v", got, tt.pass)
+			}
+		})
+	}
+}
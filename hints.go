@@ -0,0 +1,31 @@
+package webgo
+
+import (
+	"errors"
+	"net/http"
+)
+
+var ErrHintsUnavailable = errors.New("early hints are not available for this request")
+
+// EarlyHints sends a 103 response carrying the given Link header values
+// while the handler is still working on the final response, e.g.
+// req.EarlyHints(Preload("/app.css", "style")).
+func (req *Request) EarlyHints(links ...string) error {
+	if req.writer == nil || req.raw == nil || !req.raw.ProtoAtLeast(1, 1) {
+		return ErrHintsUnavailable
+	}
+	header := req.writer.Header()
+	for _, link := range links {
+		header.Add("Link", link)
+	}
+	req.writer.WriteHeader(http.StatusEarlyHints)
+	return nil
+}
+
+func Preload(url, as string) string {
+	return "<" + url + ">; rel=preload; as=" + as
+}
+
+func Preconnect(origin string) string {
+	return "<" + origin + ">; rel=preconnect"
+}
@@ -0,0 +1,41 @@
+package webgo
+
+import (
+	"context"
+)
+
+type LifecycleHook func(ctx context.Context) error
+
+// OnStart registers a hook to run before the application starts serving.
+// Hooks run in registration order; the first error aborts startup and is
+// returned from Run (or whichever serve method was used).
+func (app *Application) OnStart(hook LifecycleHook) {
+	app.startHooks = append(app.startHooks, hook)
+}
+
+// OnStop registers a hook to run, in registration order, during Shutdown
+// once in-flight requests have drained.
+func (app *Application) OnStop(hook LifecycleHook) {
+	app.stopHooks = append(app.stopHooks, hook)
+}
+
+func (app *Application) runStartHooks() error {
+	app.startOnce.Do(func() {
+		for _, hook := range app.startHooks {
+			if app.startErr = hook(context.Background()); app.startErr != nil {
+				return
+			}
+		}
+	})
+	return app.startErr
+}
+
+func (app *Application) runStopHooks(ctx context.Context) error {
+	var firstErr error
+	for _, hook := range app.stopHooks {
+		if err := hook(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,61 @@
+//go:build quic
+
+package webgo
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// RunHTTP3 serves HTTPS on addr over both TCP and QUIC. Responses sent over
+// TCP advertise the HTTP/3 endpoint with an Alt-Svc header. Only built with
+// the quic build tag.
+func (app *Application) RunHTTP3(addr, certFile, keyFile string) error {
+	if err := app.runStartHooks(); err != nil {
+		return err
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if port == "" {
+		port = "443"
+	}
+
+	h3 := &http3.Server{
+		Addr:    addr,
+		Handler: app,
+	}
+	if app.httpServer.TLSConfig != nil {
+		h3.TLSConfig = http3.ConfigureTLSConfig(app.httpServer.TLSConfig.Clone())
+	}
+	app.mu.Lock()
+	app.shutdownFuncs = append(app.shutdownFuncs, h3.Shutdown)
+	app.mu.Unlock()
+	app.altSvc = `h3=":` + port + `"; ma=2592000`
+
+	l, err := app.listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	app.setAddr(l.Addr())
+	app.notifyReady()
+
+	// QUIC runs over UDP, which the TCP listener options don't apply to
+	errc := make(chan error, 2)
+	go func() {
+		errc <- h3.ListenAndServeTLS(certFile, keyFile)
+	}()
+	go func() {
+		errc <- app.httpServer.ServeTLS(app.wrapListener(l), certFile, keyFile)
+	}()
+
+	err = <-errc
+	if err != http.ErrServerClosed {
+		app.httpServer.Close()
+		h3.Close()
+	}
+	return app.serveResult(err)
+}
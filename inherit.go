@@ -0,0 +1,94 @@
+package webgo
+
+import (
+	"net"
+	"os"
+	"strconv"
+)
+
+// Listeners can be inherited from a parent process, either a previous
+// webgo binary performing a graceful restart or systemd socket activation.
+// Both pass them as consecutive descriptors starting at fd 3.
+const (
+	inheritFdsEnv = "WEBGO_INHERIT_FDS"
+	listenFdStart = 3
+)
+
+func (app *Application) loadInherited() {
+	app.inheritOnce.Do(func() {
+		n, _ := strconv.Atoi(os.Getenv(inheritFdsEnv))
+		if n == 0 && os.Getenv("LISTEN_PID") == strconv.Itoa(os.Getpid()) {
+			n, _ = strconv.Atoi(os.Getenv("LISTEN_FDS"))
+		}
+		os.Unsetenv(inheritFdsEnv)
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+
+		for i := 0; i < n; i++ {
+			f := os.NewFile(uintptr(listenFdStart+i), "listener")
+			l, err := net.FileListener(f)
+			f.Close()
+			if err != nil {
+				app.logf("webgo: inherited fd %d is not a listener: %v", listenFdStart+i, err)
+				continue
+			}
+			app.inherited = append(app.inherited, l)
+		}
+	})
+}
+
+func sameAddr(l net.Listener, network, addr string) bool {
+	switch a := l.Addr().(type) {
+	case *net.TCPAddr:
+		if network != "tcp" && network != "tcp4" && network != "tcp6" {
+			return false
+		}
+		want, err := net.ResolveTCPAddr(network, addr)
+		if err != nil || want.Port != a.Port {
+			return false
+		}
+		return want.IP == nil || want.IP.IsUnspecified() && a.IP.IsUnspecified() || want.IP.Equal(a.IP)
+	case *net.UnixAddr:
+		return network == "unix" && a.Name == addr
+	}
+	return false
+}
+
+// listen returns an inherited listener for addr if there is one, and binds
+// a new one otherwise.
+func (app *Application) listen(network, addr string) (net.Listener, error) {
+	app.loadInherited()
+	app.mu.Lock()
+	for i, l := range app.inherited {
+		if sameAddr(l, network, addr) {
+			app.inherited = append(app.inherited[:i], app.inherited[i+1:]...)
+			app.mu.Unlock()
+			app.trackListener(l)
+			return l, nil
+		}
+	}
+	app.mu.Unlock()
+
+	if network == "unix" {
+		if info, err := os.Lstat(addr); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(addr)
+		}
+	}
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	app.trackListener(l)
+	return l, nil
+}
+
+func (app *Application) trackListener(l net.Listener) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	for _, known := range app.listeners {
+		if known == l {
+			return
+		}
+	}
+	app.listeners = append(app.listeners, l)
+}
@@ -0,0 +1,46 @@
+package webgo
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+)
+
+func JSON(status int, v interface{}) *Response {
+	buf := getBuffer()
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		putBuffer(buf)
+		return InternalError(err)
+	}
+	// the body lives in buf, recycled once the response is written
+	resp := Respond(status, buf.Bytes()[:buf.Len()-1])
+	resp.buf = buf
+	resp.Headers["Content-Type"] = contentTypeJSON
+	return resp
+}
+
+var jsonpCallbackRe = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+
+var ErrInvalidCallback = errors.New("invalid JSONP callback name")
+
+// JSONP wraps v in a call to callback. Callback names are restricted to
+// dotted JavaScript identifiers so they can't be used to inject script.
+func JSONP(status int, callback string, v interface{}) *Response {
+	if len(callback) > 128 || !jsonpCallbackRe.MatchString(callback) {
+		return BadRequest(ErrInvalidCallback)
+	}
+	buf := getBuffer()
+	buf.WriteString("/**/" + callback + "(")
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		putBuffer(buf)
+		return InternalError(err)
+	}
+	buf.Truncate(buf.Len() - 1)
+	buf.WriteString(");")
+
+	resp := Respond(status, buf.Bytes())
+	resp.buf = buf
+	resp.Headers["Content-Type"] = contentTypeJSONP
+	resp.Headers["X-Content-Type-Options"] = noSniff
+	return resp
+}
@@ -0,0 +1,288 @@
+package webgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	RPCParseError     = -32700
+	RPCInvalidRequest = -32600
+	RPCMethodNotFound = -32601
+	RPCInvalidParams  = -32602
+	RPCInternalError  = -32603
+	RPCServerError    = -32000
+)
+
+// RPCError is a JSON-RPC error object. Methods return one to control the
+// code the client sees; other errors are mapped by JSONRPC.
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func NewRPCError(code int, message string, data interface{}) *RPCError {
+	return &RPCError{Code: code, Message: message, Data: data}
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("json-rpc error %d: %s", e.Code, e.Message)
+}
+
+// JSONRPC serves JSON-RPC 2.0 calls, single or batched, POSTed to one
+// endpoint.
+type JSONRPC struct {
+	// MaxBatch limits the number of calls in a batch; zero means no limit.
+	MaxBatch int
+
+	mu      sync.RWMutex
+	methods map[string]*rpcMethod
+}
+
+type rpcMethod struct {
+	fn     reflect.Value
+	params reflect.Type
+}
+
+var (
+	requestType = reflect.TypeOf((*Request)(nil))
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+func NewJSONRPC() *JSONRPC {
+	return &JSONRPC{methods: make(map[string]*rpcMethod)}
+}
+
+// ServeJSONRPC routes POST requests on path to rpc.
+func (app *Application) ServeJSONRPC(path string, rpc *JSONRPC) *Processor {
+	return app.Route("POST "+path, rpc.Process)
+}
+
+// Register adds a method. fn must be a function of one of the forms
+//
+//	func(req *webgo.Request, params P) (R, error)
+//	func(req *webgo.Request) (R, error)
+//
+// where P and R are JSON-encodable. Params sent by position are decoded
+// into P when it is a slice or array; a single positional param is
+// decoded into P as well. Register panics if fn doesn't fit.
+func (rpc *JSONRPC) Register(name string, fn interface{}) {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumIn() < 1 || t.NumIn() > 2 || t.In(0) != requestType ||
+		t.NumOut() != 2 || t.Out(1) != errorType {
+		panic(fmt.Sprintf("webgo: JSON-RPC method %s has signature %s", name, t))
+	}
+	m := &rpcMethod{fn: v}
+	if t.NumIn() == 2 {
+		m.params = t.In(1)
+	}
+	rpc.mu.Lock()
+	defer rpc.mu.Unlock()
+	rpc.methods[name] = m
+}
+
+// Methods returns the registered method names, sorted.
+func (rpc *JSONRPC) Methods() []string {
+	rpc.mu.RLock()
+	defer rpc.mu.RUnlock()
+	names := make([]string, 0, len(rpc.methods))
+	for name := range rpc.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+func (rpc *JSONRPC) Process(req *Request) *Response {
+	body, err := req.bodyBytes()
+	if err != nil {
+		return InternalError(err)
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var calls []json.RawMessage
+		if err := json.Unmarshal(body, &calls); err != nil {
+			return JSON(200, rpcFailure(nil, NewRPCError(RPCParseError, "Parse error", nil)))
+		}
+		if len(calls) == 0 {
+			return JSON(200, rpcFailure(nil, NewRPCError(RPCInvalidRequest, "Invalid Request", nil)))
+		}
+		if rpc.MaxBatch > 0 && len(calls) > rpc.MaxBatch {
+			return JSON(200, rpcFailure(nil, NewRPCError(RPCInvalidRequest, "Invalid Request",
+				fmt.Sprintf("batch of %d calls exceeds the limit of %d", len(calls), rpc.MaxBatch))))
+		}
+		results := make([]*rpcResponse, 0, len(calls))
+		for _, call := range calls {
+			if res := rpc.call(req, call); res != nil {
+				results = append(results, res)
+			}
+		}
+		if len(results) == 0 {
+			return NoContent()
+		}
+		return JSON(200, results)
+	}
+
+	res := rpc.call(req, body)
+	if res == nil {
+		return NoContent()
+	}
+	return JSON(200, res)
+}
+
+// call runs one call and returns its response, or nil for a notification.
+func (rpc *JSONRPC) call(req *Request, raw json.RawMessage) *rpcResponse {
+	var call rpcRequest
+	if err := json.Unmarshal(raw, &call); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return rpcFailure(nil, NewRPCError(RPCParseError, "Parse error", nil))
+		}
+		return rpcFailure(nil, NewRPCError(RPCInvalidRequest, "Invalid Request", nil))
+	}
+	if call.JSONRPC != "2.0" || call.Method == "" || !validRPCID(call.ID) {
+		return rpcFailure(call.ID, NewRPCError(RPCInvalidRequest, "Invalid Request", nil))
+	}
+
+	result, rerr := rpc.invoke(req, &call)
+	if call.ID == nil {
+		return nil
+	}
+	if rerr != nil {
+		return rpcFailure(call.ID, rerr)
+	}
+	return &rpcResponse{JSONRPC: "2.0", Result: rpcResult{result}, ID: call.ID}
+}
+
+func (rpc *JSONRPC) invoke(req *Request, call *rpcRequest) (result interface{}, rerr *RPCError) {
+	rpc.mu.RLock()
+	m, ok := rpc.methods[call.Method]
+	rpc.mu.RUnlock()
+	if !ok {
+		return nil, NewRPCError(RPCMethodNotFound, "Method not found", nil)
+	}
+
+	args := []reflect.Value{reflect.ValueOf(req)}
+	if m.params != nil {
+		p, err := decodeRPCParams(call.Params, m.params)
+		if err != nil {
+			return nil, NewRPCError(RPCInvalidParams, "Invalid params", err.Error())
+		}
+		args = append(args, p)
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			if req.app != nil {
+				req.app.logf("webgo: JSON-RPC method %s panicked: %v", call.Method, v)
+			}
+			rerr = NewRPCError(RPCInternalError, "Internal error", nil)
+		}
+	}()
+	out := m.fn.Call(args)
+	if err, _ := out[1].Interface().(error); err != nil {
+		return nil, rpcErrorFor(req, call.Method, err)
+	}
+	return out[0].Interface(), nil
+}
+
+func decodeRPCParams(raw json.RawMessage, t reflect.Type) (reflect.Value, error) {
+	p := reflect.New(t)
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return p.Elem(), nil
+	}
+	if raw[0] != '[' && raw[0] != '{' {
+		return p, errors.New("params must be an array or an object")
+	}
+	err := json.Unmarshal(raw, p.Interface())
+	if err != nil && raw[0] == '[' {
+		var positional []json.RawMessage
+		if json.Unmarshal(raw, &positional) == nil && len(positional) == 1 {
+			err = json.Unmarshal(positional[0], p.Interface())
+		}
+	}
+	return p.Elem(), err
+}
+
+// rpcErrorFor maps a method's error to a JSON-RPC error: RPCErrors pass
+// through, a webgo Error with a 4xx status becomes invalid params or a
+// server error carrying its status and code, anything else an opaque
+// internal error.
+func rpcErrorFor(req *Request, method string, err error) *RPCError {
+	var rerr *RPCError
+	if errors.As(err, &rerr) {
+		return rerr
+	}
+	var e *Error
+	if errors.As(err, &e) && e.status() < 500 {
+		data := map[string]interface{}{"status": e.Status}
+		if e.Code != "" {
+			data["code"] = e.Code
+		}
+		code := RPCServerError
+		if e.Status == 400 || e.Status == 422 {
+			code = RPCInvalidParams
+		}
+		return NewRPCError(code, e.Error(), data)
+	}
+	if req.app != nil {
+		req.app.logf("webgo: JSON-RPC method %s: %v", method, err)
+	}
+	return NewRPCError(RPCInternalError, "Internal error", nil)
+}
+
+func rpcFailure(id json.RawMessage, err *RPCError) *rpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &rpcResponse{JSONRPC: "2.0", Error: err, ID: id}
+}
+
+// validRPCID reports whether id is absent, a string, a number or null.
+func validRPCID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	var v interface{}
+	if json.Unmarshal(id, &v) != nil {
+		return false
+	}
+	switch v.(type) {
+	case nil, string, float64:
+		return true
+	}
+	return false
+}
+
+// rpcResult keeps a nil result in the response: "result" is required on
+// success, and omitempty would drop it.
+type rpcResult struct {
+	v interface{}
+}
+
+func (r rpcResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.v)
+}
@@ -0,0 +1,176 @@
+package webgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// lambdaEvent covers the fields webgo needs from API Gateway REST (v1),
+// HTTP API (v2) and ALB target group events.
+type lambdaEvent struct {
+	Version                         string              `json:"version"`
+	HTTPMethod                      string              `json:"httpMethod"`
+	Path                            string              `json:"path"`
+	RawPath                         string              `json:"rawPath"`
+	RawQueryString                  string              `json:"rawQueryString"`
+	Headers                         map[string]string   `json:"headers"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	Cookies                         []string            `json:"cookies"`
+	Body                            string              `json:"body"`
+	IsBase64Encoded                 bool                `json:"isBase64Encoded"`
+	RequestContext                  struct {
+		ELB  *json.RawMessage `json:"elb"`
+		HTTP struct {
+			Method   string `json:"method"`
+			SourceIP string `json:"sourceIp"`
+		} `json:"http"`
+		Identity struct {
+			SourceIP string `json:"sourceIp"`
+		} `json:"identity"`
+	} `json:"requestContext"`
+}
+
+type lambdaResponse struct {
+	StatusCode        int                 `json:"statusCode"`
+	StatusDescription string              `json:"statusDescription,omitempty"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Cookies           []string            `json:"cookies,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// HandleLambda serves an API Gateway or ALB proxy event through the
+// application. Its signature fits lambda.Start from aws-lambda-go:
+//
+//	lambda.Start(app.HandleLambda)
+func (app *Application) HandleLambda(ctx context.Context, payload json.RawMessage) (json.RawMessage, error) {
+	var event lambdaEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	r, err := event.httpRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &bufferedResponseWriter{header: make(http.Header)}
+	app.ServeHTTP(w, r)
+	if w.status == 0 {
+		w.status = 200
+	}
+	return json.Marshal(event.response(w))
+}
+
+func (event *lambdaEvent) httpRequest(ctx context.Context) (*http.Request, error) {
+	method, path := event.HTTPMethod, event.Path
+	remoteIP := event.RequestContext.Identity.SourceIP
+	if event.Version == "2.0" {
+		method, path = event.RequestContext.HTTP.Method, event.RawPath
+		remoteIP = event.RequestContext.HTTP.SourceIP
+	}
+
+	query := event.RawQueryString
+	if query == "" {
+		values := make(url.Values)
+		for name, vs := range event.MultiValueQueryStringParameters {
+			values[name] = vs
+		}
+		if len(values) == 0 {
+			for name, v := range event.QueryStringParameters {
+				values.Set(name, v)
+			}
+		}
+		query = values.Encode()
+	}
+
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(event.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	target := path
+	if query != "" {
+		target += "?" + query
+	}
+	r, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, vs := range event.MultiValueHeaders {
+		for _, v := range vs {
+			r.Header.Add(name, v)
+		}
+	}
+	if len(event.MultiValueHeaders) == 0 {
+		for name, v := range event.Headers {
+			r.Header.Set(name, v)
+		}
+	}
+	if len(event.Cookies) > 0 {
+		r.Header.Set("Cookie", strings.Join(event.Cookies, "; "))
+	}
+	r.Host = r.Header.Get("Host")
+	r.RemoteAddr = remoteIP
+	r.RequestURI = target
+	return r, nil
+}
+
+func (event *lambdaEvent) response(w *bufferedResponseWriter) *lambdaResponse {
+	resp := &lambdaResponse{StatusCode: w.status}
+	if event.RequestContext.ELB != nil {
+		resp.StatusDescription = strconv.Itoa(w.status) + " " + http.StatusText(w.status)
+	}
+
+	switch {
+	case event.Version == "2.0":
+		resp.Headers = make(map[string]string, len(w.header))
+		for name, vs := range w.header {
+			if name == "Set-Cookie" {
+				resp.Cookies = vs
+				continue
+			}
+			resp.Headers[name] = strings.Join(vs, ",")
+		}
+	case len(event.MultiValueHeaders) > 0:
+		resp.MultiValueHeaders = w.header
+	default:
+		resp.Headers = make(map[string]string, len(w.header))
+		for name, vs := range w.header {
+			resp.Headers[name] = vs[0]
+		}
+	}
+
+	body := w.body.Bytes()
+	if isTextual(w.header.Get("Content-Type")) && utf8.Valid(body) {
+		resp.Body = string(body)
+	} else {
+		resp.Body = base64.StdEncoding.EncodeToString(body)
+		resp.IsBase64Encoded = true
+	}
+	return resp
+}
+
+func isTextual(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") ||
+		mediaType == "application/javascript" ||
+		mediaType == "application/x-www-form-urlencoded"
+}
@@ -0,0 +1,44 @@
+package webgo
+
+import (
+	"net"
+	"os"
+)
+
+// Serve serves on a listener created elsewhere, e.g. a socket handed over
+// by systemd socket activation.
+func (app *Application) Serve(l net.Listener) error {
+	if err := app.runStartHooks(); err != nil {
+		l.Close()
+		return err
+	}
+	app.setAddr(l.Addr())
+	app.trackListener(l)
+	app.notifyReady()
+	return app.serveResult(app.httpServer.Serve(app.wrapListener(l)))
+}
+
+func (app *Application) ServeTLS(l net.Listener, certFile, keyFile string) error {
+	if err := app.runStartHooks(); err != nil {
+		l.Close()
+		return err
+	}
+	app.setAddr(l.Addr())
+	app.trackListener(l)
+	app.notifyReady()
+	return app.serveResult(app.httpServer.ServeTLS(app.wrapListener(l), certFile, keyFile))
+}
+
+// RunUnix serves on a unix domain socket at path, replacing a stale socket
+// file left behind by a previous run.
+func (app *Application) RunUnix(path string, perm os.FileMode) error {
+	l, err := app.listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		l.Close()
+		return err
+	}
+	return app.Serve(l)
+}
@@ -0,0 +1,232 @@
+package webgo
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const liveReloadPath = "/__webgo/livereload"
+
+const liveReloadSnippet = `<script>(function(){var s=new EventSource("` + liveReloadPath + `"),lost=false;` +
+	`s.addEventListener("reload",function(){location.reload()});` +
+	`s.onerror=function(){lost=true};s.onopen=function(){if(lost)location.reload()}})();</script>`
+
+// LiveReload watches source directories while the application runs in dev
+// mode. When files change it reloads the attached renderers, runs OnChange
+// and tells open browser tabs to reload; the script doing so is injected
+// into every buffered HTML response that Compress hasn't already encoded.
+type LiveReload struct {
+	Interval   time.Duration
+	Extensions []string
+	OnChange   func(changed []string) error
+
+	app       *Application
+	dirs      []string
+	renderers []*Renderer
+
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+	stop    chan struct{}
+}
+
+// EnableLiveReload watches dirs (the working directory if none are given)
+// by polling. It does nothing unless dev mode is on when the application
+// starts.
+func (app *Application) EnableLiveReload(dirs ...string) *LiveReload {
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	lr := &LiveReload{
+		Interval: 500 * time.Millisecond,
+		app:      app,
+		dirs:     dirs,
+		clients:  make(map[chan struct{}]bool),
+		stop:     make(chan struct{}),
+	}
+
+	app.Route("GET "+liveReloadPath, lr.events)
+	app.Transform(lr.inject)
+	app.OnStart(func(ctx context.Context) error {
+		if app.devMode {
+			go lr.watch()
+		}
+		return nil
+	})
+	app.httpServer.RegisterOnShutdown(lr.close)
+	return lr
+}
+
+// Renderers makes the given renderers drop their parsed templates on every
+// change, so edits show up without a restart.
+func (lr *LiveReload) Renderers(renderers ...*Renderer) *LiveReload {
+	lr.renderers = append(lr.renderers, renderers...)
+	return lr
+}
+
+// Notify tells connected browsers to reload.
+func (lr *LiveReload) Notify() {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	for ch := range lr.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (lr *LiveReload) close() {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	select {
+	case <-lr.stop:
+	default:
+		close(lr.stop)
+	}
+}
+
+func (lr *LiveReload) events(req *Request) *Response {
+	if !lr.app.devMode {
+		return NotFound("")
+	}
+	ch := make(chan struct{}, 1)
+	lr.mu.Lock()
+	lr.clients[ch] = true
+	lr.mu.Unlock()
+
+	resp := Stream(200, func(w *StreamWriter) error {
+		defer func() {
+			lr.mu.Lock()
+			delete(lr.clients, ch)
+			lr.mu.Unlock()
+		}()
+		if _, err := w.WriteString(": connected\n\n"); err != nil {
+			return err
+		}
+		w.Flush()
+		for {
+			select {
+			case <-ch:
+				if _, err := w.WriteString("event: reload\ndata: \n\n"); err != nil {
+					return err
+				}
+				w.Flush()
+			case <-req.Context().Done():
+				return nil
+			case <-lr.stop:
+				return nil
+			}
+		}
+	})
+	resp.Headers.Set("Content-Type", "text/event-stream")
+	resp.Headers.Set("Cache-Control", "no-cache")
+	return resp
+}
+
+func (lr *LiveReload) inject(req *Request, resp *Response) *Response {
+	if !lr.app.devMode || resp.BodyReader != nil || resp.StreamFunc != nil || resp.serveHTTP != nil {
+		return nil
+	}
+	// a compressed body can't have the snippet appended
+	if !strings.HasPrefix(resp.Headers.Get("Content-Type"), "text/html") ||
+		resp.Headers.Get("Content-Encoding") != "" {
+		return nil
+	}
+	body := string(resp.Body)
+	if i := strings.LastIndex(body, "</body>"); i >= 0 {
+		body = body[:i] + liveReloadSnippet + body[i:]
+	} else {
+		body += liveReloadSnippet
+	}
+	resp.Body = []byte(body)
+	return nil
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func (lr *LiveReload) watch() {
+	ticker := time.NewTicker(lr.Interval)
+	defer ticker.Stop()
+
+	prev := lr.scan()
+	for {
+		select {
+		case <-lr.stop:
+			return
+		case <-ticker.C:
+		}
+		cur := lr.scan()
+		var changed []string
+		for path, stamp := range cur {
+			if old, ok := prev[path]; !ok || old != stamp {
+				changed = append(changed, path)
+			}
+		}
+		for path := range prev {
+			if _, ok := cur[path]; !ok {
+				changed = append(changed, path)
+			}
+		}
+		prev = cur
+		if len(changed) == 0 {
+			continue
+		}
+
+		for _, r := range lr.renderers {
+			r.Reload()
+		}
+		if lr.OnChange != nil {
+			if err := lr.OnChange(changed); err != nil {
+				lr.app.logf("webgo: live reload: %v", err)
+				continue
+			}
+		}
+		lr.Notify()
+	}
+}
+
+// scan records every watched file, skipping hidden directories.
+func (lr *LiveReload) scan() map[string]fileStamp {
+	files := make(map[string]fileStamp)
+	for _, dir := range lr.dirs {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != dir && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !lr.watched(path) {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				files[path] = fileStamp{info.ModTime(), info.Size()}
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+func (lr *LiveReload) watched(path string) bool {
+	if len(lr.Extensions) == 0 {
+		return true
+	}
+	ext := filepath.Ext(path)
+	for _, e := range lr.Extensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package webgo
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLiveReloadInject(t *testing.T) {
+	page := "<html><body>" + strings.Repeat("hello ", 400) + "</body></html>"
+	for _, tt := range []struct {
+		name     string
+		encoding string
+		snippet  bool
+	}{
+		{"Plain", "", true},
+		{"Gzip", "gzip", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewApplication()
+			app.SetDevMode(true)
+			app.EnableLiveReload(t.TempDir())
+			app.Use(Compress(CompressOptions{}))
+			app.Route("GET /", func(req *Request) *Response {
+				return HTML(200, page)
+			})
+
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.encoding != "" {
+				r.Header.Set("Accept-Encoding", tt.encoding)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			body := w.Body.String()
+			if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+			if tt.encoding == "gzip" {
+				zr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, err := ioutil.ReadAll(zr)
+				if err != nil {
+					t.Fatalf("corrupt gzip body: %v", err)
+				}
+				body = string(b)
+			}
+			if got := strings.Contains(body, liveReloadSnippet); got != tt.snippet {
+				t.Errorf("snippet injected = %v, want %v", got, tt.snippet)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package webgo
+
+import (
+	"log"
+	"strings"
+)
+
+// Logger receives the errors webgo can't report to anyone else: failed
+// reads and writes, handler errors behind 500 responses, TLS handshake
+// failures and the like. *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+type loggerWriter struct {
+	logger Logger
+}
+
+func (w loggerWriter) Write(p []byte) (int, error) {
+	w.logger.Printf("%s", strings.TrimRight(string(p), "\n"))
+	return len(p), nil
+}
+
+// SetLogger routes webgo's internal errors, and those of the underlying
+// http.Server, to logger.
+func (app *Application) SetLogger(logger Logger) {
+	app.logger = logger
+	app.httpServer.ErrorLog = log.New(loggerWriter{logger}, "", 0)
+}
+
+func (app *Application) logf(format string, args ...interface{}) {
+	if app.logger != nil {
+		app.logger.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
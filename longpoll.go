@@ -0,0 +1,75 @@
+package webgo
+
+import (
+	"sync"
+	"time"
+)
+
+// LongPoll waits up to timeout for a value on ch and responds with it as
+// JSON, or with 204 No Content if none arrives in time or ch is closed.
+// It gives up as soon as the client goes away.
+func LongPoll(req *Request, ch <-chan interface{}, timeout time.Duration) *Response {
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			return NoContent()
+		}
+		return JSON(200, v)
+	case <-req.Clock().After(timeout):
+		return NoContent()
+	case <-req.Context().Done():
+		return NoContent()
+	}
+}
+
+// LongPollUntil waits up to timeout for check to report a result, running
+// it once up front and again whenever sig is notified. It responds like
+// LongPoll. A typical check compares a version against a cursor the
+// client sent:
+//
+//	return webgo.LongPollUntil(req, 30*time.Second, changed, func() (interface{}, bool) {
+//		return feed.Since(cursor)
+//	})
+func LongPollUntil(req *Request, timeout time.Duration, sig *Signal, check func() (interface{}, bool)) *Response {
+	deadline := req.Clock().After(timeout)
+	for {
+		// take the channel first so a Notify during check isn't missed
+		wake := sig.Wait()
+		if v, ok := check(); ok {
+			return JSON(200, v)
+		}
+		select {
+		case <-wake:
+		case <-deadline:
+			return NoContent()
+		case <-req.Context().Done():
+			return NoContent()
+		}
+	}
+}
+
+// Signal wakes every goroutine waiting on it at once. The zero value is
+// ready to use.
+type Signal struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+// Wait returns a channel closed on the next Notify.
+func (s *Signal) Wait() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+	}
+	return s.ch
+}
+
+func (s *Signal) Notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ch != nil {
+		close(s.ch)
+		s.ch = nil
+	}
+}
@@ -0,0 +1,257 @@
+package webgo
+
+import (
+	"bytes"
+	"errors"
+	"html"
+	"html/template"
+	"io/fs"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MarkdownToHTML converts a practical subset of Markdown (headings, lists,
+// quotes, fenced code, rules, emphasis, code spans, links and images) to
+// HTML. Raw HTML in the source is escaped and only http, https, mailto and
+// relative URLs are linked, so the output is safe to embed as is.
+func MarkdownToHTML(src []byte) template.HTML {
+	var buf bytes.Buffer
+	lines := strings.Split(strings.ReplaceAll(string(src), "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case strings.HasPrefix(trimmed, "```"):
+			lang := strings.TrimSpace(trimmed[3:])
+			i++
+			var code []string
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```") {
+				code = append(code, lines[i])
+				i++
+			}
+			i++
+			if lang != "" {
+				buf.WriteString(`<pre><code class="language-` + html.EscapeString(lang) + `">`)
+			} else {
+				buf.WriteString("<pre><code>")
+			}
+			buf.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			buf.WriteString("</code></pre>\n")
+
+		case mdHeadingRe.MatchString(trimmed):
+			m := mdHeadingRe.FindStringSubmatch(trimmed)
+			level := string(rune('0' + len(m[1])))
+			buf.WriteString("<h" + level + ">" + mdInline(strings.TrimRight(m[2], " #")) + "</h" + level + ">\n")
+			i++
+
+		case mdRuleRe.MatchString(trimmed):
+			buf.WriteString("<hr>\n")
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			var quote []string
+			for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">") {
+				l := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quote = append(quote, strings.TrimPrefix(l, " "))
+				i++
+			}
+			buf.WriteString("<blockquote>\n")
+			buf.WriteString(string(MarkdownToHTML([]byte(strings.Join(quote, "\n")))))
+			buf.WriteString("</blockquote>\n")
+
+		case mdBulletRe.MatchString(line) || mdOrderedRe.MatchString(line):
+			re, tag := mdBulletRe, "ul"
+			if mdOrderedRe.MatchString(line) {
+				re, tag = mdOrderedRe, "ol"
+			}
+			buf.WriteString("<" + tag + ">\n")
+			for i < len(lines) && re.MatchString(lines[i]) {
+				item := re.ReplaceAllString(lines[i], "")
+				i++
+				for i < len(lines) && strings.HasPrefix(lines[i], "  ") && strings.TrimSpace(lines[i]) != "" {
+					item += " " + strings.TrimSpace(lines[i])
+					i++
+				}
+				buf.WriteString("<li>" + mdInline(item) + "</li>\n")
+			}
+			buf.WriteString("</" + tag + ">\n")
+
+		default:
+			var para []string
+			for i < len(lines) && mdParagraphLine(lines[i]) {
+				para = append(para, strings.TrimSpace(lines[i]))
+				i++
+			}
+			if len(para) == 0 {
+				para = append(para, trimmed)
+				i++
+			}
+			buf.WriteString("<p>" + mdInline(strings.Join(para, "\n")) + "</p>\n")
+		}
+	}
+	return template.HTML(buf.String())
+}
+
+var (
+	mdHeadingRe = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdRuleRe    = regexp.MustCompile(`^([-*_])(\s*[-*_]){2,}$`)
+	mdBulletRe  = regexp.MustCompile(`^\s{0,3}[-*+]\s+`)
+	mdOrderedRe = regexp.MustCompile(`^\s{0,3}\d{1,9}[.)]\s+`)
+
+	mdImageRe  = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	mdLinkRe   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdAutoRe   = regexp.MustCompile(`&lt;((?:https?|mailto):[^\s&]+)&gt;`)
+	mdStrongRe = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	mdEmRe     = regexp.MustCompile(`\*([^*\s][^*]*)\*|\b_([^_\s][^_]*)_\b`)
+	mdTagRe    = regexp.MustCompile(`<[^>]*>`)
+	mdSlotRe   = regexp.MustCompile(`<(\d+)>`)
+)
+
+func mdParagraphLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed != "" &&
+		!strings.HasPrefix(trimmed, "```") &&
+		!strings.HasPrefix(trimmed, ">") &&
+		!mdHeadingRe.MatchString(trimmed) &&
+		!mdRuleRe.MatchString(trimmed) &&
+		!mdBulletRe.MatchString(line) &&
+		!mdOrderedRe.MatchString(line)
+}
+
+func mdSafeURL(escaped string) (string, bool) {
+	raw := html.UnescapeString(escaped)
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return html.EscapeString(raw), true
+	}
+	return "", false
+}
+
+func mdInline(text string) string {
+	var buf strings.Builder
+	for {
+		start := strings.IndexByte(text, '`')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(text[start+1:], '`')
+		if end < 0 {
+			break
+		}
+		buf.WriteString(mdSpan(text[:start]))
+		buf.WriteString("<code>" + html.EscapeString(text[start+1:start+1+end]) + "</code>")
+		text = text[start+end+2:]
+	}
+	buf.WriteString(mdSpan(text))
+	return buf.String()
+}
+
+func mdSpan(text string) string {
+	s := html.EscapeString(text)
+	s = mdImageRe.ReplaceAllStringFunc(s, func(m string) string {
+		parts := mdImageRe.FindStringSubmatch(m)
+		src, ok := mdSafeURL(parts[2])
+		if !ok {
+			return parts[1]
+		}
+		return `<img src="` + src + `" alt="` + parts[1] + `">`
+	})
+	s = mdLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		parts := mdLinkRe.FindStringSubmatch(m)
+		href, ok := mdSafeURL(parts[2])
+		if !ok {
+			return parts[1]
+		}
+		return `<a href="` + href + `">` + parts[1] + `</a>`
+	})
+	s = mdAutoRe.ReplaceAllString(s, `<a href="$1">$1</a>`)
+	s = mdEmphasis(s)
+	return strings.ReplaceAll(s, "\n", "<br>\n")
+}
+
+// mdEmphasis applies emphasis to s outside the tags already in it, so
+// URLs containing * or _ survive. The text is escaped, so any < starts a
+// tag; tags are swapped for numbered slots while the regexps run.
+func mdEmphasis(s string) string {
+	var tags []string
+	s = mdTagRe.ReplaceAllStringFunc(s, func(tag string) string {
+		tags = append(tags, tag)
+		return "<" + strconv.Itoa(len(tags)-1) + ">"
+	})
+	s = mdStrongRe.ReplaceAllString(s, "<strong>$1$2</strong>")
+	s = mdEmRe.ReplaceAllString(s, "<em>$1$2</em>")
+	return mdSlotRe.ReplaceAllStringFunc(s, func(slot string) string {
+		n, _ := strconv.Atoi(slot[1 : len(slot)-1])
+		return tags[n]
+	})
+}
+
+type MarkdownPage struct {
+	Title   string
+	Content template.HTML
+	Data    interface{}
+}
+
+func markdownTitle(src []byte) string {
+	for _, line := range strings.Split(string(src), "\n") {
+		if m := mdHeadingRe.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			return strings.TrimRight(m[2], " #")
+		}
+	}
+	return ""
+}
+
+// Markdown renders src inside layout, which receives a *MarkdownPage. A nil
+// layout responds with the bare HTML fragment.
+func Markdown(status int, layout *template.Template, src []byte, data interface{}) *Response {
+	content := MarkdownToHTML(src)
+	if layout == nil {
+		return HTML(status, string(content))
+	}
+
+	var buf bytes.Buffer
+	page := &MarkdownPage{
+		Title:   markdownTitle(src),
+		Content: content,
+		Data:    data,
+	}
+	if err := layout.Execute(&buf, page); err != nil {
+		return InternalError(err)
+	}
+	return HTML(status, buf.String())
+}
+
+func MarkdownFile(layout *template.Template, path string, data interface{}) *Response {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return markdownReadError(err)
+	}
+	return Markdown(200, layout, src, data)
+}
+
+func MarkdownFS(layout *template.Template, fsys fs.FS, name string, data interface{}) *Response {
+	src, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return markdownReadError(err)
+	}
+	return Markdown(200, layout, src, data)
+}
+
+func markdownReadError(err error) *Response {
+	if errors.Is(err, fs.ErrNotExist) {
+		return NotFound("")
+	}
+	return InternalError(err)
+}
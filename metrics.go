@@ -0,0 +1,214 @@
+package webgo
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	DefaultSizeBuckets    = []float64{100, 1000, 10000, 100000, 1e6, 1e7}
+)
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+type routeKey struct {
+	method string
+	route  string
+}
+
+type routeMetrics struct {
+	statuses map[int]uint64
+	latency  *histogram
+	size     *histogram
+}
+
+// Metrics collects per-route request counts, latencies and response sizes
+// and exposes them in the Prometheus text format. Routes are labelled by
+// their pattern rather than the raw path to keep cardinality bounded.
+type Metrics struct {
+	Namespace      string
+	LatencyBuckets []float64
+	SizeBuckets    []float64
+
+	mu       sync.Mutex
+	routes   map[routeKey]*routeMetrics
+	inFlight atomic.Int64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		Namespace:      "webgo",
+		LatencyBuckets: DefaultLatencyBuckets,
+		SizeBuckets:    DefaultSizeBuckets,
+		routes:         make(map[routeKey]*routeMetrics),
+	}
+}
+
+// EnableMetrics instruments every request and serves the metrics at path.
+func (app *Application) EnableMetrics(path string) *Metrics {
+	m := NewMetrics()
+	app.Use(m.Middleware)
+	app.Route("GET "+path, m.Process)
+	return m
+}
+
+var knownMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true,
+	"DELETE": true, "OPTIONS": true, "CONNECT": true, "TRACE": true,
+}
+
+func metricLabels(req *Request) routeKey {
+	method := req.Method
+	if !knownMethods[method] {
+		method = "OTHER"
+	}
+	route := req.Route()
+	if route == "" {
+		route = "unmatched"
+	}
+	return routeKey{method, route}
+}
+
+func responseSize(resp *Response) int {
+	if resp.BodyReader == nil && resp.StreamFunc == nil {
+		return len(resp.Body)
+	}
+	n, err := strconv.Atoi(resp.Headers.Get("Content-Length"))
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func (m *Metrics) Middleware(next ProcessFunc) ProcessFunc {
+	return func(req *Request) *Response {
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+
+		start := req.Clock().Now()
+		resp := next(req)
+		m.observe(metricLabels(req), resp.Status, req.Clock().Since(start), responseSize(resp))
+		return resp
+	}
+}
+
+func (m *Metrics) observe(key routeKey, status int, latency time.Duration, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rm := m.routes[key]
+	if rm == nil {
+		rm = &routeMetrics{
+			statuses: make(map[int]uint64),
+			latency:  newHistogram(m.LatencyBuckets),
+			size:     newHistogram(m.SizeBuckets),
+		}
+		m.routes[key] = rm
+	}
+	rm.statuses[status]++
+	rm.latency.observe(latency.Seconds())
+	if size >= 0 {
+		rm.size.observe(float64(size))
+	}
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func writeHistogram(buf *bytes.Buffer, name, labels string, h *histogram) {
+	for i, bound := range h.buckets {
+		fmt.Fprintf(buf, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(bound), h.counts[i])
+	}
+	fmt.Fprintf(buf, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(buf, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+	fmt.Fprintf(buf, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+// render formats all metrics in the Prometheus text exposition format.
+func (m *Metrics) render() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]routeKey, 0, len(m.routes))
+	for key := range m.routes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].method < keys[j].method
+	})
+	labels := func(key routeKey) string {
+		return fmt.Sprintf(`method="%s",route="%s"`, key.method, escapeLabel(key.route))
+	}
+
+	var buf bytes.Buffer
+	ns := m.Namespace
+
+	fmt.Fprintf(&buf, "# HELP %s_requests_total Requests handled, by route and status.\n", ns)
+	fmt.Fprintf(&buf, "# TYPE %s_requests_total counter\n", ns)
+	for _, key := range keys {
+		statuses := make([]int, 0, len(m.routes[key].statuses))
+		for status := range m.routes[key].statuses {
+			statuses = append(statuses, status)
+		}
+		sort.Ints(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(&buf, "%s_requests_total{%s,status=\"%d\"} %d\n", ns, labels(key), status, m.routes[key].statuses[status])
+		}
+	}
+
+	fmt.Fprintf(&buf, "# HELP %s_request_duration_seconds Time spent handling requests.\n", ns)
+	fmt.Fprintf(&buf, "# TYPE %s_request_duration_seconds histogram\n", ns)
+	for _, key := range keys {
+		writeHistogram(&buf, ns+"_request_duration_seconds", labels(key), m.routes[key].latency)
+	}
+
+	fmt.Fprintf(&buf, "# HELP %s_response_size_bytes Size of response bodies.\n", ns)
+	fmt.Fprintf(&buf, "# TYPE %s_response_size_bytes histogram\n", ns)
+	for _, key := range keys {
+		writeHistogram(&buf, ns+"_response_size_bytes", labels(key), m.routes[key].size)
+	}
+
+	fmt.Fprintf(&buf, "# HELP %s_requests_in_flight Requests currently being handled.\n", ns)
+	fmt.Fprintf(&buf, "# TYPE %s_requests_in_flight gauge\n", ns)
+	fmt.Fprintf(&buf, "%s_requests_in_flight %d\n", ns, m.inFlight.Load())
+	return buf.Bytes()
+}
+
+func (m *Metrics) Process(req *Request) *Response {
+	resp := Respond(200, m.render())
+	resp.Headers.Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	return resp
+}
@@ -0,0 +1,30 @@
+package webgo
+
+type Middleware func(next ProcessFunc) ProcessFunc
+
+// Use appends middleware wrapping every request, including those that end
+// in a 404. The first registered middleware is the outermost one.
+func (app *Application) Use(mws ...Middleware) {
+	app.middleware = append(app.middleware, mws...)
+}
+
+// Use wraps this route's handler in mws, inside the application-wide
+// middleware. Like Application.Use, the first one given is outermost. It
+// is for setting routes up before serving; use WithRouteMiddleware after.
+func (p *Processor) Use(mws ...Middleware) *Processor {
+	p.Process = wrap(p.Process, mws)
+	p.middleware = append(append([]Middleware(nil), mws...), p.middleware...)
+	return p
+}
+
+func (app *Application) chain(handler ProcessFunc) ProcessFunc {
+	return wrap(handler, app.middleware)
+}
+
+// wrap applies mws to handler, the first one ending up outermost.
+func wrap(handler ProcessFunc, mws []Middleware) ProcessFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	return handler
+}
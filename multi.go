@@ -0,0 +1,97 @@
+package webgo
+
+import (
+	"net"
+	"net/http"
+)
+
+// Endpoint describes one address for RunAll to listen on.
+type Endpoint struct {
+	Network  string // "tcp" when empty, or "unix"
+	Addr     string
+	CertFile string // serve TLS when set, or when TLS is true
+	KeyFile  string
+	TLS      bool
+	Handler  http.Handler // the application itself when nil
+}
+
+// newServer returns a server configured like the application's own, for
+// listeners that need a different handler.
+func (app *Application) newServer(handler http.Handler) *http.Server {
+	base := app.httpServer
+	server := &http.Server{
+		Handler:           handler,
+		TLSConfig:         base.TLSConfig,
+		ReadTimeout:       base.ReadTimeout,
+		ReadHeaderTimeout: base.ReadHeaderTimeout,
+		WriteTimeout:      base.WriteTimeout,
+		IdleTimeout:       base.IdleTimeout,
+		MaxHeaderBytes:    base.MaxHeaderBytes,
+		ErrorLog:          base.ErrorLog,
+		BaseContext:       base.BaseContext,
+		ConnContext:       base.ConnContext,
+		ConnState:         base.ConnState,
+		Protocols:         base.Protocols,
+	}
+	app.mu.Lock()
+	app.shutdownFuncs = append(app.shutdownFuncs, server.Shutdown)
+	app.mu.Unlock()
+	return server
+}
+
+// RunAll serves on all endpoints at once. They are shut down together, and
+// if any of them fails the others are closed and the error is returned.
+func (app *Application) RunAll(endpoints ...Endpoint) error {
+	if err := app.runStartHooks(); err != nil {
+		return err
+	}
+	listeners := make([]net.Listener, 0, len(endpoints))
+	closeAll := func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}
+	for _, ep := range endpoints {
+		network := ep.Network
+		if network == "" {
+			network = "tcp"
+		}
+		l, err := app.listen(network, ep.Addr)
+		if err != nil {
+			closeAll()
+			return err
+		}
+		listeners = append(listeners, l)
+	}
+
+	if len(listeners) > 0 {
+		app.setAddr(listeners[0].Addr())
+	}
+	app.notifyReady()
+	servers := []*http.Server{app.httpServer}
+	errc := make(chan error, len(endpoints))
+	for i, ep := range endpoints {
+		server := app.httpServer
+		if ep.Handler != nil {
+			server = app.newServer(ep.Handler)
+			servers = append(servers, server)
+		}
+		go func(server *http.Server, l net.Listener, ep Endpoint) {
+			l = app.wrapListener(l)
+			if ep.TLS || ep.CertFile != "" {
+				errc <- server.ServeTLS(l, ep.CertFile, ep.KeyFile)
+			} else {
+				errc <- server.Serve(l)
+			}
+		}(server, listeners[i], ep)
+	}
+
+	err := <-errc
+	if err != http.ErrServerClosed {
+		for _, server := range servers {
+			server.Close()
+		}
+		closeAll()
+	}
+	return app.serveResult(err)
+}
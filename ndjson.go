@@ -0,0 +1,107 @@
+package webgo
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Seq is a push iterator, compatible with iter.Seq[any].
+type Seq func(yield func(interface{}) bool)
+
+func ChanSeq(ch <-chan interface{}) Seq {
+	return func(yield func(interface{}) bool) {
+		for v := range ch {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func SliceSeq(items []interface{}) Seq {
+	return func(yield func(interface{}) bool) {
+		for _, v := range items {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+const (
+	streamFlushItems    = 128
+	streamFlushInterval = 200 * time.Millisecond
+)
+
+type periodicFlusher struct {
+	sw        *StreamWriter
+	pending   int
+	lastFlush time.Time
+}
+
+func (f *periodicFlusher) wrote() {
+	f.pending++
+	if f.pending >= streamFlushItems || time.Since(f.lastFlush) >= streamFlushInterval {
+		f.sw.Flush()
+		f.pending = 0
+		f.lastFlush = time.Now()
+	}
+}
+
+// NDJSON streams every value of seq as one line of JSON.
+func NDJSON(status int, seq Seq) *Response {
+	resp := Stream(status, func(sw *StreamWriter) error {
+		enc := json.NewEncoder(sw)
+		flusher := &periodicFlusher{sw: sw, lastFlush: time.Now()}
+		var err error
+		seq(func(v interface{}) bool {
+			if err = enc.Encode(v); err != nil {
+				return false
+			}
+			flusher.wrote()
+			return true
+		})
+		sw.Flush()
+		return err
+	})
+	resp.Headers.Set("Content-Type", "application/x-ndjson")
+	return resp
+}
+
+// JSONArray streams seq as a single JSON array, encoding one element at a
+// time. If encoding fails midway the array is left unterminated so clients
+// see a parse error rather than a silently truncated result.
+func JSONArray(status int, seq Seq) *Response {
+	resp := Stream(status, func(sw *StreamWriter) error {
+		flusher := &periodicFlusher{sw: sw, lastFlush: time.Now()}
+		sep := []byte{'['}
+		var err error
+		seq(func(v interface{}) bool {
+			var item []byte
+			if item, err = json.Marshal(v); err != nil {
+				return false
+			}
+			if _, err = sw.Write(sep); err != nil {
+				return false
+			}
+			if _, err = sw.Write(item); err != nil {
+				return false
+			}
+			sep[0] = ','
+			flusher.wrote()
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if sep[0] == '[' {
+			_, err = sw.WriteString("[]")
+		} else {
+			_, err = sw.WriteString("]")
+		}
+		sw.Flush()
+		return err
+	})
+	resp.Headers.Set("Content-Type", "application/json; charset=utf-8")
+	return resp
+}
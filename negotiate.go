@@ -0,0 +1,121 @@
+package webgo
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+}
+
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaType == "" {
+			continue
+		}
+		if mediaType == "*" {
+			mediaType = "*/*"
+		}
+		slash := strings.IndexByte(mediaType, '/')
+		if slash < 0 {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.TrimSpace(name) == "q" {
+				if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = v
+				}
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType[:slash], mediaType[slash+1:], q})
+	}
+	return ranges
+}
+
+// match reports how specifically ar matches the media type; -1 means it
+// doesn't match at all.
+func (ar acceptRange) match(typ, subtype string) int {
+	switch {
+	case ar.typ == typ && ar.subtype == subtype:
+		return 2
+	case ar.typ == typ && ar.subtype == "*":
+		return 1
+	case ar.typ == "*" && ar.subtype == "*":
+		return 0
+	}
+	return -1
+}
+
+func quality(ranges []acceptRange, mediaType string) (float64, int) {
+	mediaType = strings.ToLower(mediaType)
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = strings.TrimSpace(mediaType[:i])
+	}
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+
+	q, specificity := 0.0, -1
+	for _, ar := range ranges {
+		if s := ar.match(typ, subtype); s > specificity {
+			q, specificity = ar.q, s
+		}
+	}
+	return q, specificity
+}
+
+// NegotiateType picks the offered media type the client prefers according
+// to its Accept header, or "" if none is acceptable. Ties go to the more
+// specific match, then to the earlier offer.
+func NegotiateType(req *Request, offers []string) string {
+	accept := req.Headers.Get("Accept")
+	if accept == "" {
+		if len(offers) == 0 {
+			return ""
+		}
+		return offers[0]
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ, bestSpecificity := "", 0.0, -1
+	for _, offer := range offers {
+		q, specificity := quality(ranges, offer)
+		if q <= 0 || specificity < 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && specificity > bestSpecificity) {
+			best, bestQ, bestSpecificity = offer, q, specificity
+		}
+	}
+	return best
+}
+
+// Negotiate renders the response for the media type the client prefers.
+// Offers are tried in sorted order when the client has no preference.
+func Negotiate(req *Request, renderers map[string]func() *Response) *Response {
+	offers := make([]string, 0, len(renderers))
+	for mediaType := range renderers {
+		offers = append(offers, mediaType)
+	}
+	sort.Strings(offers)
+
+	var resp *Response
+	if mediaType := NegotiateType(req, offers); mediaType != "" {
+		resp = renderers[mediaType]()
+		if resp.Headers.Get("Content-Type") == "" {
+			resp.SetHeader("Content-Type", mediaType)
+		}
+	} else {
+		resp = statusText(http.StatusNotAcceptable, "")
+	}
+	resp.AddHeader("Vary", "Accept")
+	return resp
+}
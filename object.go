@@ -0,0 +1,208 @@
+package webgo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+	ETag        string
+}
+
+// ObjectStore is blob storage objects can be served from, such as a local
+// directory or an S3 bucket. Open returns length bytes starting at offset,
+// or everything from offset on if length is negative; for S3 that is a
+// GetObject with a Range header.
+type ObjectStore interface {
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	Open(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+}
+
+// ObjectSigner is implemented by stores that can hand out time-limited
+// URLs clients may download objects from directly.
+type ObjectSigner interface {
+	SignURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+type ObjectOptions struct {
+	CacheControl string
+
+	// Download makes browsers save the object instead of displaying it,
+	// under Filename or else the key's last element.
+	Download bool
+	Filename string
+
+	// Redirect, if positive and the store is an ObjectSigner, answers with
+	// a redirect to a URL signed for that long instead of streaming the
+	// object through the application.
+	Redirect time.Duration
+}
+
+// Object serves the object at key from store. The body is read from the
+// store while it is written, starting at the range asked for, so large
+// media is never held in memory; Range, If-Range and conditional requests
+// work as with File.
+func Object(req *Request, store ObjectStore, key string, opts ObjectOptions) *Response {
+	ctx := req.Context()
+	if signer, ok := store.(ObjectSigner); ok && opts.Redirect > 0 {
+		u, err := signer.SignURL(ctx, key, opts.Redirect)
+		if err != nil {
+			return InternalError(err)
+		}
+		resp := Respond(307, nil)
+		resp.Headers.Set("Location", u)
+		resp.Headers.Set("Cache-Control", "private, max-age="+strconv.Itoa(int(opts.Redirect/time.Second/2)))
+		return resp
+	}
+
+	info, err := store.Stat(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return NotFound("")
+		}
+		return InternalError(err)
+	}
+
+	resp := Respond(200, nil)
+	resp.BodyReader = &objectReader{ctx: ctx, store: store, key: key, size: info.Size}
+	resp.name = path.Base(key)
+	resp.modTime = info.ModTime
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if contentType == "" {
+		// don't let ServeContent sniff, it would fetch the object twice
+		contentType = "application/octet-stream"
+	}
+	resp.Headers.Set("Content-Type", contentType)
+	if info.ETag != "" {
+		etag := info.ETag
+		if !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+			etag = strconv.Quote(etag)
+		}
+		resp.Headers.Set("ETag", etag)
+	}
+	if opts.CacheControl != "" {
+		resp.Headers.Set("Cache-Control", opts.CacheControl)
+	}
+	if opts.Download {
+		name := opts.Filename
+		if name == "" {
+			name = path.Base(key)
+		}
+		resp.Headers.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	}
+	return resp
+}
+
+// objectReader is an io.ReadSeeker over an object, opening a ranged read
+// on the first Read after each Seek.
+type objectReader struct {
+	ctx    context.Context
+	store  ObjectStore
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *objectReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.store.Open(r.ctx, r.key, r.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *objectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, errors.New("webgo: seek before start of object")
+	}
+	if offset != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+func (r *objectReader) Close() error {
+	if r.body != nil {
+		return r.body.Close()
+	}
+	return nil
+}
+
+// DirObjectStore serves objects from files below Dir, keys being slash
+// separated paths relative to it.
+type DirObjectStore struct {
+	Dir string
+}
+
+func (s DirObjectStore) path(key string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+func (s DirObjectStore) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	fi, err := os.Stat(s.path(key))
+	if os.IsNotExist(err) || (err == nil && fi.IsDir()) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectInfo{
+		Size:    fi.Size(),
+		ModTime: fi.ModTime(),
+		ETag:    strconv.FormatInt(fi.ModTime().UnixNano(), 36) + "-" + strconv.FormatInt(fi.Size(), 36),
+	}, nil
+}
+
+func (s DirObjectStore) Open(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(f, length), f}, nil
+}
@@ -0,0 +1,467 @@
+package webgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RouteDoc annotates a route for the generated OpenAPI document. Request
+// and response bodies are given as example values (usually zero values of
+// the Go types) whose schemas are derived by reflection.
+type RouteDoc struct {
+	Summary     string
+	Description string
+	OperationID string
+	Tags        []string
+	Params      []ParamDoc
+	Request     interface{}
+	Responses   map[int]ResponseDoc
+	Hidden      bool
+}
+
+// ParamDoc describes a parameter. In is "path", "query" or "header". Path
+// parameters name the route's capture groups in order, unless the pattern
+// already names them with (?P<name>...).
+type ParamDoc struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Type        interface{}
+}
+
+type ResponseDoc struct {
+	Description string
+	Body        interface{}
+}
+
+// Describe attaches documentation to a route:
+//
+//	app.Route(`GET /users/(\d+)`, getUser).Describe(webgo.RouteDoc{
+//		Summary:   "Fetch a user",
+//		Params:    []webgo.ParamDoc{{Name: "id", In: "path", Type: 0}},
+//		Responses: map[int]webgo.ResponseDoc{200: {Body: User{}}},
+//	})
+func (p *Processor) Describe(doc RouteDoc) *Processor {
+	p.doc = &doc
+	return p
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenAPISpec struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components *OpenAPIComponents                      `json:"components,omitempty"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	OperationID string                      `json:"operationId,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *OpenAPISchema `json:"schema,omitempty"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema,omitempty"`
+}
+
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+}
+
+// OpenAPI builds an OpenAPI 3 document from the registered routes. Routes
+// whose patterns cannot be expressed as an OpenAPI path template, such as
+// mounts and wildcards, are left out, as are routes marked Hidden.
+func (app *Application) OpenAPI(info OpenAPIInfo) *OpenAPISpec {
+	spec := &OpenAPISpec{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+	}
+	schemas := &schemaBuilder{components: make(map[string]*OpenAPISchema)}
+
+	for _, p := range app.table().processors {
+		doc := p.doc
+		if doc == nil {
+			doc = &RouteDoc{}
+		}
+		if doc.Hidden {
+			continue
+		}
+		methods, path, captures, ok := openAPIPath(p.Pattern, doc)
+		if !ok {
+			continue
+		}
+		for _, method := range methods {
+			ops := spec.Paths[path]
+			if ops == nil {
+				ops = make(map[string]*OpenAPIOperation)
+				spec.Paths[path] = ops
+			}
+			if _, exists := ops[method]; exists {
+				// an earlier route already serves it
+				continue
+			}
+			ops[method] = schemas.operation(doc, captures)
+		}
+	}
+
+	if len(schemas.components) > 0 {
+		spec.Components = &OpenAPIComponents{Schemas: schemas.components}
+	}
+	return spec
+}
+
+func (app *Application) WriteOpenAPI(w io.Writer, info OpenAPIInfo) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(app.OpenAPI(info))
+}
+
+// ServeOpenAPI serves the document at path. It is regenerated on each
+// request so routes added later are included.
+func (app *Application) ServeOpenAPI(path string, info OpenAPIInfo) {
+	app.Route("GET "+path, func(req *Request) *Response {
+		return JSON(200, app.OpenAPI(info))
+	}, WithRouteDoc(RouteDoc{Hidden: true}))
+}
+
+// ServeSwaggerUI serves a Swagger UI page at path for the document at
+// specURL. The UI's scripts and styles are loaded from the unpkg CDN.
+func (app *Application) ServeSwaggerUI(path, specURL string) {
+	url, _ := json.Marshal(specURL)
+	page := fmt.Sprintf(swaggerUIPage, url)
+	app.Route("GET "+path, func(req *Request) *Response {
+		return HTML(200, page)
+	}, WithRouteDoc(RouteDoc{Hidden: true}))
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>API documentation</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>SwaggerUIBundle({url: %s, dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`
+
+type pathCapture struct {
+	name   string
+	schema *OpenAPISchema
+}
+
+// openAPIPath converts a route pattern into lower-case methods and an
+// OpenAPI path template, replacing capture groups with {name}.
+func openAPIPath(pattern string, doc *RouteDoc) ([]string, string, []pathCapture, bool) {
+	methods := []string{"get"}
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		methods = openAPIMethods(pattern[:i])
+		pattern = pattern[i+1:]
+	}
+	if methods == nil {
+		return nil, "", nil, false
+	}
+
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, "", nil, false
+	}
+	var pathNames []string
+	for _, param := range doc.Params {
+		if param.In == "path" {
+			pathNames = append(pathNames, param.Name)
+		}
+	}
+
+	var b strings.Builder
+	var captures []pathCapture
+	var walk func(re *syntax.Regexp) bool
+	walk = func(re *syntax.Regexp) bool {
+		switch re.Op {
+		case syntax.OpLiteral:
+			b.WriteString(string(re.Rune))
+		case syntax.OpConcat:
+			for _, sub := range re.Sub {
+				if !walk(sub) {
+					return false
+				}
+			}
+		case syntax.OpCapture:
+			name := re.Name
+			if name == "" && len(captures) < len(pathNames) {
+				name = pathNames[len(captures)]
+			}
+			if name == "" {
+				name = "arg" + strconv.Itoa(len(captures)+1)
+			}
+			schema := &OpenAPISchema{Type: "string"}
+			if digitsOnly(re.Sub[0]) {
+				schema.Type = "integer"
+			}
+			captures = append(captures, pathCapture{name, schema})
+			b.WriteString("{" + name + "}")
+		case syntax.OpEmptyMatch, syntax.OpBeginText, syntax.OpEndText, syntax.OpBeginLine, syntax.OpEndLine:
+		case syntax.OpQuest:
+			// an optional trailing slash, which Route ignores anyway
+			if re.Sub[0].Op != syntax.OpLiteral || string(re.Sub[0].Rune) != "/" {
+				return false
+			}
+		default:
+			return false
+		}
+		return true
+	}
+	if !walk(re) {
+		return nil, "", nil, false
+	}
+
+	path := strings.TrimRight(b.String(), "/")
+	if path == "" {
+		path = "/"
+	}
+	return methods, path, captures, true
+}
+
+func openAPIMethods(pattern string) []string {
+	if pattern == ".*" {
+		return []string{"get"}
+	}
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "("), ")")
+	var methods []string
+	for _, m := range strings.Split(pattern, "|") {
+		if m == "" || strings.ToUpper(m) != m || strings.ContainsAny(m, `\.*+?[](){}^$`) {
+			return nil
+		}
+		methods = append(methods, strings.ToLower(m))
+	}
+	return methods
+}
+
+func digitsOnly(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpPlus, syntax.OpStar, syntax.OpRepeat:
+		return digitsOnly(re.Sub[0])
+	case syntax.OpCharClass:
+		return len(re.Rune) == 2 && re.Rune[0] == '0' && re.Rune[1] == '9'
+	}
+	return false
+}
+
+type schemaBuilder struct {
+	components map[string]*OpenAPISchema
+}
+
+func (sb *schemaBuilder) operation(doc *RouteDoc, captures []pathCapture) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		Summary:     doc.Summary,
+		Description: doc.Description,
+		OperationID: doc.OperationID,
+		Tags:        doc.Tags,
+		Responses:   make(map[string]*OpenAPIResponse),
+	}
+
+	documented := make(map[string]ParamDoc)
+	for _, param := range doc.Params {
+		if param.In == "path" {
+			documented[param.Name] = param
+		}
+	}
+	for _, c := range captures {
+		param := &OpenAPIParameter{Name: c.name, In: "path", Required: true, Schema: c.schema}
+		if d, ok := documented[c.name]; ok {
+			param.Description = d.Description
+			if d.Type != nil {
+				param.Schema = sb.schema(reflect.TypeOf(d.Type))
+			}
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+	for _, d := range doc.Params {
+		if d.In == "path" {
+			continue
+		}
+		schema := &OpenAPISchema{Type: "string"}
+		if d.Type != nil {
+			schema = sb.schema(reflect.TypeOf(d.Type))
+		}
+		op.Parameters = append(op.Parameters, &OpenAPIParameter{
+			Name:        d.Name,
+			In:          d.In,
+			Description: d.Description,
+			Required:    d.Required,
+			Schema:      schema,
+		})
+	}
+
+	if doc.Request != nil {
+		op.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content:  sb.content(doc.Request),
+		}
+	}
+
+	if len(doc.Responses) == 0 {
+		op.Responses["200"] = &OpenAPIResponse{Description: "OK"}
+	}
+	for status, r := range doc.Responses {
+		resp := &OpenAPIResponse{Description: r.Description}
+		if resp.Description == "" {
+			resp.Description = http.StatusText(status)
+		}
+		if r.Body != nil {
+			resp.Content = sb.content(r.Body)
+		}
+		op.Responses[strconv.Itoa(status)] = resp
+	}
+	return op
+}
+
+func (sb *schemaBuilder) content(v interface{}) map[string]*OpenAPIMediaType {
+	return map[string]*OpenAPIMediaType{
+		"application/json": {Schema: sb.schema(reflect.TypeOf(v))},
+	}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+func (sb *schemaBuilder) schema(t reflect.Type) *OpenAPISchema {
+	switch t {
+	case timeType:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &OpenAPISchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := sb.schema(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: sb.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: sb.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return sb.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := sb.components[name]; !ok {
+			// reserve the name first so recursive types terminate
+			sb.components[name] = &OpenAPISchema{}
+			*sb.components[name] = *sb.structSchema(t)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+	}
+	return &OpenAPISchema{}
+}
+
+func (sb *schemaBuilder) structSchema(t reflect.Type) *OpenAPISchema {
+	s := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+	sb.addFields(s, t)
+	sort.Strings(s.Required)
+	return s
+}
+
+func (sb *schemaBuilder) addFields(s *OpenAPISchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				sb.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = sb.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package webgo
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+type Option func(*Application)
+
+func WithLogger(logger Logger) Option {
+	return func(app *Application) {
+		app.SetLogger(logger)
+	}
+}
+
+func WithTimeouts(t Timeouts) Option {
+	return func(app *Application) {
+		app.SetTimeouts(t)
+	}
+}
+
+func WithMaxHeaderBytes(n int) Option {
+	return func(app *Application) {
+		app.SetMaxHeaderBytes(n)
+	}
+}
+
+func WithBodySpill(threshold int64, dir string) Option {
+	return func(app *Application) {
+		app.SetBodySpill(threshold, dir)
+	}
+}
+
+func WithRequestPooling() Option {
+	return func(app *Application) {
+		app.EnableRequestPooling()
+	}
+}
+
+func WithResponsePooling() Option {
+	return func(app *Application) {
+		app.EnableResponsePooling()
+	}
+}
+
+func WithTLS(config *tls.Config) Option {
+	return func(app *Application) {
+		app.TLSConfig(config)
+	}
+}
+
+func WithH2C() Option {
+	return func(app *Application) {
+		app.EnableH2C()
+	}
+}
+
+func WithErrorHandler(h ErrorHandler) Option {
+	return func(app *Application) {
+		app.SetErrorHandler(h)
+	}
+}
+
+func WithShutdownDelay(d time.Duration) Option {
+	return func(app *Application) {
+		app.SetShutdownDelay(d)
+	}
+}
+
+func WithMaxConnections(n int) Option {
+	return func(app *Application) {
+		app.SetMaxConnections(n)
+	}
+}
+
+func WithKeepAlives(enabled bool) Option {
+	return func(app *Application) {
+		app.SetKeepAlivesEnabled(enabled)
+	}
+}
+
+// WithoutRecovery lets handler panics propagate to net/http instead of
+// being turned into 500 responses.
+func WithoutRecovery() Option {
+	return func(app *Application) {
+		app.recovery = false
+	}
+}
+
+func (app *Application) process(handler ProcessFunc, req *Request) (resp *Response) {
+	if app.recovery {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+				stack := debug.Stack()
+				app.reportPanic(req, recovered, stack)
+				resp = InternalError(fmt.Errorf("panic: %v\n%s", recovered, stack))
+				resp.stack = stack
+			}
+		}()
+	}
+	return app.chain(handler)(req)
+}
@@ -0,0 +1,60 @@
+//go:build otel
+
+package webgo
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/t4ng/webgo"
+
+// Tracing starts a server span per request, continuing any trace the
+// client propagated, and makes it available through req.Context(). Spans
+// are named after the matched route pattern. A nil provider or propagator
+// means the otel globals. Only built with the otel build tag.
+func Tracing(tp trace.TracerProvider, propagator propagation.TextMapPropagator) Middleware {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+	tracer := tp.Tracer(tracerName)
+
+	return func(next ProcessFunc) ProcessFunc {
+		return func(req *Request) *Response {
+			ctx := propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Headers))
+			route := req.Route()
+			name := req.Method
+			if route != "" {
+				name += " " + route
+			}
+
+			ctx, span := tracer.Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", req.Method),
+					attribute.String("http.route", route),
+					attribute.String("url.path", req.Path),
+					attribute.String("client.address", req.RemoteAddr()),
+				),
+			)
+			defer span.End()
+			req.SetContext(ctx)
+
+			resp := next(req)
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.Status))
+			if resp.Status >= 500 {
+				span.SetStatus(codes.Error, "")
+				if resp.err != nil {
+					span.RecordError(resp.err)
+				}
+			}
+			return resp
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package webgo
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Requests and Responses are only recycled with EnableRequestPooling and
+// EnableResponsePooling; otherwise each request gets new ones.
+var (
+	requestPool  = sync.Pool{New: func() interface{} { return new(Request) }}
+	responsePool = sync.Pool{New: func() interface{} { return new(Response) }}
+)
+
+func acquireRequest() *Request {
+	return requestPool.Get().(*Request)
+}
+
+func releaseRequest(req *Request) {
+	req.removeBodyFile()
+	*req = Request{}
+	requestPool.Put(req)
+}
+
+func acquireResponse() *Response {
+	resp := responsePool.Get().(*Response)
+	if resp.headers == nil {
+		resp.headers = make(http.Header)
+	}
+	resp.Headers = resp.headers
+	resp.pooled = true
+	return resp
+}
+
+// EnableRequestPooling recycles Requests once ServeHTTP has written the
+// response. Handlers and middleware must then not keep a Request, or hand
+// it to a goroutine, past that point: it is reset under them. What it
+// holds, like Query and Arguments, is made afresh for every request and
+// may be kept.
+func (app *Application) EnableRequestPooling() {
+	app.poolRequests = true
+}
+
+// EnableResponsePooling recycles Responses made by Respond, and the
+// buffers JSON encodes into, once they have been written, saving their
+// allocations on busy servers. Handlers must then make a new Response
+// for every request: one kept in a variable and returned again would be
+// reset under them.
+func (app *Application) EnableResponsePooling() {
+	app.poolResponses = true
+}
+
+// releaseResponse recycles resp if it came from Respond. Only the header
+// map Respond made is reused; one a handler put in its place may be
+// shared, so it is left alone.
+func releaseResponse(resp *Response) {
+	if !resp.pooled {
+		return
+	}
+	headers := resp.headers
+	for name := range headers {
+		delete(headers, name)
+	}
+	if resp.buf != nil {
+		putBuffer(resp.buf)
+	}
+	*resp = Response{headers: headers}
+	responsePool.Put(resp)
+}
+
+var (
+	bufferPool  = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+	copyBufPool = sync.Pool{New: func() interface{} { b := make([]byte, 32<<10); return &b }}
+)
+
+// maxPooledBuffer keeps the odd huge body from pinning its memory in the
+// pool for good.
+const maxPooledBuffer = 1 << 20
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBuffer {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
+
+// copyBuffered is io.Copy with a pooled buffer. Like io.Copy it lets dst
+// or src do the copy themselves when they can, as with sendfile.
+func copyBuffered(dst io.Writer, src io.Reader) (int64, error) {
+	buf := copyBufPool.Get().(*[]byte)
+	defer copyBufPool.Put(buf)
+	return io.CopyBuffer(dst, src, *buf)
+}
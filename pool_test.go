@@ -0,0 +1,30 @@
+package webgo
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestPooling(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		path string
+	}{
+		{"Default", nil, "/kept"},
+		{"Pooled", []Option{WithRequestPooling()}, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewApplication(tt.opts...)
+			var kept *Request
+			app.Route("GET /kept", func(req *Request) *Response {
+				kept = req
+				return NoContent()
+			})
+			app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/kept", nil))
+			if kept.Path != tt.path {
+				t.Errorf("kept request has Path %q after ServeHTTP, want %q", kept.Path, tt.path)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package webgo
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+func (p *Problem) With(name string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+	p.Extensions[name] = value
+	return p
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Title + ": " + p.Detail
+	}
+	return p.Title
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]interface{}, len(p.Extensions)+5)
+	for name, value := range p.Extensions {
+		fields[name] = value
+	}
+	fields["type"] = p.Type
+	if p.Type == "" {
+		fields["type"] = "about:blank"
+	}
+	fields["title"] = p.Title
+	fields["status"] = p.Status
+	if p.Detail != "" {
+		fields["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		fields["instance"] = p.Instance
+	}
+	return json.Marshal(fields)
+}
+
+// RespondProblem sends p as problem+json. A Problem without a Status is
+// sent as a 500.
+func RespondProblem(p *Problem) *Response {
+	if p.Status == 0 {
+		cp := *p
+		cp.Status = http.StatusInternalServerError
+		if cp.Title == "" {
+			cp.Title = http.StatusText(cp.Status)
+		}
+		p = &cp
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return InternalError(err)
+	}
+	resp := Respond(p.Status, body)
+	resp.Headers.Set("Content-Type", "application/problem+json")
+	resp.err = p
+	return resp
+}
+
+type ErrorHandler func(*Request, error) *Response
+
+func (app *Application) SetErrorHandler(h ErrorHandler) {
+	app.errorHandler = h
+}
+
+// HandleError turns err into a Response using the handler set with
+// SetErrorHandler, falling back to problem+json for *Problem and *Error
+// values and a plain 500 for everything else.
+func (app *Application) HandleError(req *Request, err error) *Response {
+	if app.errorHandler != nil {
+		return app.errorHandler(req, err)
+	}
+	return defaultErrorHandler(req, err)
+}
+
+func defaultErrorHandler(req *Request, err error) *Response {
+	var p *Problem
+	if errors.As(err, &p) {
+		if p.Instance == "" && req != nil {
+			cp := *p
+			cp.Instance = req.Path
+			p = &cp
+		}
+		resp := RespondProblem(p)
+		resp.err = err
+		return resp
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		resp := RespondProblem(e.Problem())
+		resp.err = err
+		return resp
+	}
+	return InternalError(err)
+}
@@ -0,0 +1,260 @@
+package webgo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProxyOptions configures Proxy. The zero value forwards with
+// http.DefaultTransport and no timeout.
+type ProxyOptions struct {
+	Transport http.RoundTripper
+
+	// Timeout bounds the whole exchange, including reading the body;
+	// exceeding it before the response headers arrive yields a 504.
+	Timeout time.Duration
+
+	// StripPrefix is removed from the request path before it is appended
+	// to the target's path.
+	StripPrefix string
+
+	// PreserveHost sends the client's Host header instead of the target's.
+	PreserveHost bool
+
+	// FlushInterval, if non-zero, streams the response body with a flush
+	// after each write (negative) or at most that often. Event streams
+	// are always flushed immediately.
+	FlushInterval time.Duration
+
+	// Guard, if set and Transport is not, makes the proxy refuse to
+	// connect to internal addresses, for targets chosen by Rewrite from
+	// request data.
+	Guard *SSRFGuard
+
+	// Cache, if set, keeps GET responses the upstream marks cacheable and
+	// serves them while fresh, revalidating them once stale.
+	Cache ProxyCache
+
+	Rewrite        func(out *http.Request)
+	ModifyResponse func(resp *Response)
+}
+
+// hopHeaders apply to a single connection and must not be forwarded.
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// Proxy returns a handler forwarding requests to target, e.g.
+//
+//	app.Route("/api/.*", webgo.Proxy("http://backend:8080", webgo.ProxyOptions{StripPrefix: "/api"}),
+//		webgo.WithStreamBody())
+//
+// The upstream response body is streamed to the client. The request body
+// is only streamed upstream on routes marked with StreamBody, as above;
+// elsewhere it is read in full first. Application.Proxy registers such a
+// route. Connection failures map to 502 Bad Gateway and timeouts to 504
+// Gateway Timeout. It panics if target is not an absolute URL.
+func Proxy(target string, opts ProxyOptions) ProcessFunc {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		panic("webgo: invalid proxy target " + target)
+	}
+	transport := opts.transport()
+	fetch := func(req *Request) (*Response, error) {
+		return forward(req, u, transport, opts, nil)
+	}
+	return func(req *Request) *Response {
+		resp, err := serveCached(req, opts.Cache, fetch)
+		if err != nil {
+			return proxyError(req, err)
+		}
+		return resp
+	}
+}
+
+// Proxy routes pattern to target, streaming bodies both ways:
+//
+//	app.Proxy("/api/.*", "http://backend:8080", webgo.ProxyOptions{StripPrefix: "/api"})
+func (app *Application) Proxy(pattern, target string, opts ProxyOptions, ropts ...RouteOption) *Processor {
+	ropts = append([]RouteOption{WithStreamBody()}, ropts...)
+	return app.Route(pattern, Proxy(target, opts), ropts...)
+}
+
+func (opts ProxyOptions) transport() http.RoundTripper {
+	switch {
+	case opts.Transport != nil:
+		return opts.Transport
+	case opts.Guard != nil:
+		return opts.Guard.Transport()
+	}
+	return http.DefaultTransport
+}
+
+// forward sends req to target and returns the upstream response, whose
+// body is streamed. done, if set, runs once that body has been closed.
+func forward(req *Request, target *url.URL, transport http.RoundTripper, opts ProxyOptions, done func()) (*Response, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+	}
+	out := proxyRequest(ctx, req, target, opts)
+	if opts.Rewrite != nil {
+		opts.Rewrite(out)
+	}
+
+	upstream, err := transport.RoundTrip(out)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return proxyResponse(upstream, func() {
+		cancel()
+		if done != nil {
+			done()
+		}
+	}, opts), nil
+}
+
+func proxyRequest(ctx context.Context, req *Request, target *url.URL, opts ProxyOptions) *http.Request {
+	path := strings.TrimPrefix(req.Path, opts.StripPrefix)
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	u := *target
+	u.Path = strings.TrimRight(target.Path, "/") + path
+	u.RawPath = ""
+	if req.raw != nil {
+		u.RawQuery = req.raw.URL.RawQuery
+	}
+
+	body := req.BodyReader()
+	out, _ := http.NewRequestWithContext(ctx, req.Method, u.String(), body)
+	if sr, ok := body.(*io.SectionReader); ok {
+		out.ContentLength = sr.Size()
+	}
+	out.Header = req.Headers.Clone()
+	if out.Header == nil {
+		out.Header = make(http.Header)
+	}
+	removeHopHeaders(out.Header)
+
+	host, proto := "", "http"
+	if req.raw != nil {
+		host = req.raw.Host
+		if req.raw.TLS != nil {
+			proto = "https"
+		}
+	}
+	if opts.PreserveHost {
+		out.Host = host
+	}
+	if ip, _, err := net.SplitHostPort(req.RemoteAddr()); err == nil {
+		if prior := out.Header.Get("X-Forwarded-For"); prior != "" {
+			ip = prior + ", " + ip
+		}
+		out.Header.Set("X-Forwarded-For", ip)
+	}
+	if host != "" {
+		out.Header.Set("X-Forwarded-Host", host)
+	}
+	out.Header.Set("X-Forwarded-Proto", proto)
+	return out
+}
+
+func proxyResponse(upstream *http.Response, closed func(), opts ProxyOptions) *Response {
+	removeHopHeaders(upstream.Header)
+	resp := &Response{
+		Status:     upstream.StatusCode,
+		Headers:    upstream.Header,
+		BodyReader: &closeHook{ReadCloser: upstream.Body, fn: closed},
+	}
+
+	interval := opts.FlushInterval
+	if strings.HasPrefix(upstream.Header.Get("Content-Type"), "text/event-stream") {
+		interval = -1
+	}
+	if interval != 0 {
+		body := resp.BodyReader
+		resp.StreamFunc = func(w *StreamWriter) error {
+			return copyFlushing(w, body, interval)
+		}
+	}
+
+	if opts.ModifyResponse != nil {
+		opts.ModifyResponse(resp)
+	}
+	return resp
+}
+
+func proxyError(req *Request, err error) *Response {
+	if req.app != nil {
+		req.app.logf("webgo: proxying %s %s: %v", req.Method, req.Path, err)
+	}
+	if err == ErrNoBackend {
+		return ServiceUnavailable("")
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return statusText(http.StatusGatewayTimeout, "")
+	}
+	return statusText(http.StatusBadGateway, "")
+}
+
+func removeHopHeaders(h http.Header) {
+	for _, f := range h.Values("Connection") {
+		for _, name := range strings.Split(f, ",") {
+			h.Del(strings.TrimSpace(name))
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
+
+// copyFlushing copies src to w, flushing after every write when interval
+// is negative and otherwise at most once per interval.
+func copyFlushing(w *StreamWriter, src io.Reader, interval time.Duration) error {
+	buf := make([]byte, 32*1024)
+	last := time.Now()
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			if interval < 0 || time.Since(last) >= interval {
+				w.Flush()
+				last = time.Now()
+			}
+		}
+		if err == io.EOF {
+			w.Flush()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// closeHook runs fn once the body is closed, to release the request's
+// timeout context and the backend's connection count.
+type closeHook struct {
+	io.ReadCloser
+	fn   func()
+	once sync.Once
+}
+
+func (c *closeHook) Close() error {
+	err := c.ReadCloser.Close()
+	c.once.Do(c.fn)
+	return err
+}
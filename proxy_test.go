@@ -0,0 +1,59 @@
+package webgo
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestApplicationProxyStreamsRequestBody(t *testing.T) {
+	// the first chunk must reach the upstream while the client is still
+	// sending: a proxy buffering the body would only forward it at the end
+	firstChunk := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chunk := make([]byte, 5)
+		if _, err := io.ReadFull(r.Body, chunk); err != nil {
+			t.Errorf("upstream read: %v", err)
+			return
+		}
+		close(firstChunk)
+		rest, _ := ioutil.ReadAll(r.Body)
+		w.Write(append(chunk, rest...))
+	}))
+	defer upstream.Close()
+
+	app := NewApplication()
+	app.Proxy("POST /upload", upstream.URL, ProxyOptions{})
+	ts := httptest.NewServer(app)
+	defer ts.Close()
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	result := make(chan string, 1)
+	go func() {
+		resp, err := http.Post(ts.URL+"/upload", "text/plain", pr)
+		if err != nil {
+			t.Error(err)
+			result <- ""
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		result <- string(body)
+	}()
+
+	pw.Write([]byte("hello"))
+	select {
+	case <-firstChunk:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request body was not streamed upstream")
+	}
+	pw.Write([]byte(" world"))
+	pw.Close()
+	if body := <-result; body != "hello world" {
+		t.Errorf("got body %q, want %q", body, "hello world")
+	}
+}
@@ -0,0 +1,308 @@
+package webgo
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCachedBody is the largest upstream body the proxy cache buffers;
+// bigger responses are streamed through uncached.
+const maxCachedBody = 10 << 20
+
+// CachedResponse is an upstream response kept by a ProxyCache.
+type CachedResponse struct {
+	Status  int
+	Header  http.Header
+	Body    []byte
+	Stored  time.Time
+	Expires time.Time
+
+	// Variant holds the request's values of the headers named by Vary;
+	// only requests with the same values are served this entry.
+	Variant string
+}
+
+// ProxyCache stores upstream responses for Proxy and LoadBalancer, keyed
+// by host, path and query. Implementations must be safe for concurrent use.
+// Entries are never modified once stored, so they may be handed to several
+// requests at once.
+type ProxyCache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, entry *CachedResponse)
+	Delete(key string)
+}
+
+// serveCached answers req from cache when possible and otherwise calls
+// fetch, storing cacheable results. A nil cache just calls fetch.
+func serveCached(req *Request, cache ProxyCache, fetch func(*Request) (*Response, error)) (*Response, error) {
+	if cache == nil || (req.Method != "GET" && req.Method != "HEAD") || req.Headers.Get("Authorization") != "" {
+		return fetch(req)
+	}
+	reqCC := parseCacheControl(req.Headers.Values("Cache-Control"))
+	if _, ok := reqCC["no-store"]; ok {
+		return fetch(req)
+	}
+
+	key := proxyCacheKey(req)
+	now := req.Clock().Now()
+	entry, ok := cache.Get(key)
+	if ok && entry.Variant != varyValues(entry.Header, req.Headers) {
+		ok = false
+	}
+	_, noCache := reqCC["no-cache"]
+	if ok && !noCache && now.Before(entry.Expires) {
+		return cachedResponse(req, entry, now, "HIT"), nil
+	}
+
+	out := req
+	if ok {
+		// stale: ask the upstream whether our copy is still good
+		conditional := *req
+		conditional.Headers = req.Headers.Clone()
+		conditional.Headers.Del("If-None-Match")
+		conditional.Headers.Del("If-Modified-Since")
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			conditional.Headers.Set("If-None-Match", etag)
+		}
+		if lm := entry.Header.Get("Last-Modified"); lm != "" {
+			conditional.Headers.Set("If-Modified-Since", lm)
+		}
+		out = &conditional
+	}
+	if req.Method == "HEAD" {
+		// fetch the body too, so the entry can serve GETs
+		get := *out
+		get.Method = "GET"
+		out = &get
+	}
+
+	resp, err := fetch(out)
+	if err != nil {
+		return nil, err
+	}
+	if ok && resp.Status == http.StatusNotModified {
+		closeBody(resp)
+		// other requests may be reading the stored entry: update a copy
+		fresh := *entry
+		fresh.Header = entry.Header.Clone()
+		for name, values := range resp.Headers {
+			fresh.Header[name] = append([]string(nil), values...)
+		}
+		fresh.Stored, fresh.Expires = now, now.Add(freshness(fresh.Header, now))
+		cache.Set(key, &fresh)
+		return cachedResponse(req, &fresh, now, "REVALIDATED"), nil
+	}
+
+	expires, cacheable := cacheableResponse(resp, now)
+	if !cacheable {
+		resp.SetHeader("X-Cache", "MISS")
+		return resp, nil
+	}
+	body, complete := bufferBody(resp)
+	if !complete {
+		resp.SetHeader("X-Cache", "MISS")
+		return resp, nil
+	}
+	entry = &CachedResponse{
+		Status:  resp.Status,
+		Header:  resp.Headers.Clone(),
+		Body:    body,
+		Stored:  now,
+		Expires: expires,
+		Variant: varyValues(resp.Headers, req.Headers),
+	}
+	cache.Set(key, entry)
+	return cachedResponse(req, entry, now, "MISS"), nil
+}
+
+func proxyCacheKey(req *Request) string {
+	key := req.Path
+	if req.raw != nil {
+		key = req.raw.Host + key
+		if req.raw.URL.RawQuery != "" {
+			key += "?" + req.raw.URL.RawQuery
+		}
+	}
+	return key
+}
+
+func cachedResponse(req *Request, entry *CachedResponse, now time.Time, state string) *Response {
+	resp := Respond(entry.Status, entry.Body)
+	for name, values := range entry.Header {
+		resp.Headers[name] = append([]string(nil), values...)
+	}
+	resp.Headers.Set("Age", strconv.Itoa(int(now.Sub(entry.Stored)/time.Second)))
+	resp.Headers.Set("X-Cache", state)
+	if etag := entry.Header.Get("ETag"); etag != "" && req.Headers.Get("If-None-Match") == etag {
+		resp.Status, resp.Body = http.StatusNotModified, nil
+	}
+	return resp
+}
+
+// cacheableResponse applies the shared-cache rules of RFC 9111 to resp and
+// returns when it stops being fresh.
+func cacheableResponse(resp *Response, now time.Time) (time.Time, bool) {
+	switch resp.Status {
+	case 200, 203, 204, 300, 301, 404, 410:
+	default:
+		return time.Time{}, false
+	}
+	if resp.Headers.Get("Set-Cookie") != "" || resp.Headers.Get("Vary") == "*" {
+		return time.Time{}, false
+	}
+	cc := parseCacheControl(resp.Headers.Values("Cache-Control"))
+	for _, directive := range []string{"no-store", "private", "no-cache"} {
+		if _, ok := cc[directive]; ok {
+			return time.Time{}, false
+		}
+	}
+	ttl := freshness(resp.Headers, now)
+	if ttl <= 0 {
+		return time.Time{}, false
+	}
+	return now.Add(ttl), true
+}
+
+func freshness(h http.Header, now time.Time) time.Duration {
+	cc := parseCacheControl(h.Values("Cache-Control"))
+	for _, directive := range []string{"s-maxage", "max-age"} {
+		if v, ok := cc[directive]; ok {
+			secs, err := strconv.Atoi(v)
+			if err != nil {
+				return 0
+			}
+			return time.Duration(secs) * time.Second
+		}
+	}
+	if expires, err := http.ParseTime(h.Get("Expires")); err == nil {
+		return expires.Sub(now)
+	}
+	return 0
+}
+
+func parseCacheControl(values []string) map[string]string {
+	cc := make(map[string]string)
+	for _, value := range values {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name != "" {
+				cc[strings.ToLower(name)] = strings.Trim(arg, `"`)
+			}
+		}
+	}
+	return cc
+}
+
+func varyValues(respHeader, reqHeader http.Header) string {
+	var b strings.Builder
+	for _, vary := range respHeader.Values("Vary") {
+		for _, name := range strings.Split(vary, ",") {
+			name = strings.TrimSpace(name)
+			b.WriteString(name + "=" + strings.Join(reqHeader.Values(name), ",") + "\n")
+		}
+	}
+	return b.String()
+}
+
+// bufferBody reads a streamed body into memory for caching. If it is too
+// big, the response is left streaming what was read plus the rest.
+func bufferBody(resp *Response) ([]byte, bool) {
+	if resp.BodyReader == nil {
+		// Body may be in a pooled buffer; the cache outlives it
+		return append([]byte(nil), resp.Body...), true
+	}
+	if resp.StreamFunc != nil {
+		// flushed as it arrives, like event streams: not worth keeping
+		return nil, false
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.BodyReader, maxCachedBody+1))
+	if err != nil || len(body) > maxCachedBody {
+		rest := resp.BodyReader
+		resp.BodyReader = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), rest), closerOf(rest)}
+		return nil, false
+	}
+	closeBody(resp)
+	resp.BodyReader, resp.StreamFunc = nil, nil
+	return body, true
+}
+
+func closerOf(r io.Reader) io.Closer {
+	if c, ok := r.(io.Closer); ok {
+		return c
+	}
+	return ioutil.NopCloser(nil)
+}
+
+func closeBody(resp *Response) {
+	if c, ok := resp.BodyReader.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// MemoryProxyCache is an in-memory ProxyCache evicting the least recently
+// used entries beyond MaxEntries.
+type MemoryProxyCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CachedResponse
+}
+
+func NewMemoryProxyCache(maxEntries int) *MemoryProxyCache {
+	return &MemoryProxyCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (c *MemoryProxyCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*memoryCacheItem).entry, true
+}
+
+func (c *MemoryProxyCache) Set(key string, entry *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*memoryCacheItem).entry = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&memoryCacheItem{key, entry})
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheItem).key)
+	}
+}
+
+func (c *MemoryProxyCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+}
@@ -0,0 +1,232 @@
+package webgo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrProxyHeader = errors.New("webgo: malformed PROXY protocol header")
+
+	proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+const proxyHeaderTimeout = 5 * time.Second
+
+// EnableProxyProtocol makes the application's listeners accept HAProxy
+// PROXY protocol v1 and v2 headers, so RemoteAddr reports the real client.
+// Headers are only honoured from peers within the trusted CIDR ranges;
+// connections from anywhere else are served as is.
+func (app *Application) EnableProxyProtocol(trusted ...string) error {
+	nets := make([]*net.IPNet, 0, len(trusted))
+	for _, cidr := range trusted {
+		if !strings.Contains(cidr, "/") {
+			if strings.Contains(cidr, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return err
+		}
+		nets = append(nets, n)
+	}
+	app.proxyTrusted = nets
+	app.proxyProtocol = true
+	return nil
+}
+
+type proxyListener struct {
+	net.Listener
+	trusted []*net.IPNet
+}
+
+func (pl *proxyListener) Accept() (net.Conn, error) {
+	c, err := pl.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !pl.isTrusted(c.RemoteAddr()) {
+		return c, nil
+	}
+	return &proxyConn{Conn: c, reader: bufio.NewReader(c)}, nil
+}
+
+func (pl *proxyListener) isTrusted(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok {
+		// unix sockets are only reachable by local front ends
+		return true
+	}
+	for _, n := range pl.trusted {
+		if n.Contains(tcp.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// proxyConn reads the PROXY header lazily on first use, so a slow client
+// can't hold up the accept loop.
+type proxyConn struct {
+	net.Conn
+	reader *bufio.Reader
+	once   sync.Once
+	remote net.Addr
+	local  net.Addr
+	err    error
+
+	// the read deadline the server set, restored after the header
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+func (pc *proxyConn) init() {
+	pc.once.Do(func() {
+		pc.mu.Lock()
+		outer := pc.deadline
+		pc.mu.Unlock()
+		deadline := time.Now().Add(proxyHeaderTimeout)
+		if !outer.IsZero() && outer.Before(deadline) {
+			deadline = outer
+		}
+		pc.Conn.SetReadDeadline(deadline)
+		pc.remote, pc.local, pc.err = readProxyHeader(pc.reader)
+
+		pc.mu.Lock()
+		pc.Conn.SetReadDeadline(pc.deadline)
+		pc.mu.Unlock()
+	})
+}
+
+func (pc *proxyConn) SetReadDeadline(t time.Time) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.deadline = t
+	return pc.Conn.SetReadDeadline(t)
+}
+
+func (pc *proxyConn) SetDeadline(t time.Time) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.deadline = t
+	return pc.Conn.SetDeadline(t)
+}
+
+func (pc *proxyConn) Read(p []byte) (int, error) {
+	pc.init()
+	if pc.err != nil {
+		return 0, pc.err
+	}
+	return pc.reader.Read(p)
+}
+
+func (pc *proxyConn) RemoteAddr() net.Addr {
+	pc.init()
+	if pc.remote != nil {
+		return pc.remote
+	}
+	return pc.Conn.RemoteAddr()
+}
+
+func (pc *proxyConn) LocalAddr() net.Addr {
+	pc.init()
+	if pc.local != nil {
+		return pc.local
+	}
+	return pc.Conn.LocalAddr()
+}
+
+// readProxyHeader consumes a v1 or v2 header. Connections without a header
+// are left untouched and report nil addresses.
+func readProxyHeader(r *bufio.Reader) (net.Addr, net.Addr, error) {
+	peek, err := r.Peek(len(proxyV2Signature))
+	if err != nil && len(peek) == 0 {
+		return nil, nil, err
+	}
+	if bytes.Equal(peek, proxyV2Signature) {
+		return readProxyV2(r)
+	}
+	if bytes.HasPrefix(peek, []byte("PROXY ")) {
+		return readProxyV1(r)
+	}
+	return nil, nil, nil
+}
+
+func readProxyV1(r *bufio.Reader) (net.Addr, net.Addr, error) {
+	var line []byte
+	for len(line) < 107 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, nil, ErrProxyHeader
+	}
+
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, nil, ErrProxyHeader
+	}
+	src, dst := net.ParseIP(fields[2]), net.ParseIP(fields[3])
+	srcPort, err1 := strconv.ParseUint(fields[4], 10, 16)
+	dstPort, err2 := strconv.ParseUint(fields[5], 10, 16)
+	if src == nil || dst == nil || err1 != nil || err2 != nil {
+		return nil, nil, ErrProxyHeader
+	}
+	return &net.TCPAddr{IP: src, Port: int(srcPort)}, &net.TCPAddr{IP: dst, Port: int(dstPort)}, nil
+}
+
+func readProxyV2(r *bufio.Reader) (net.Addr, net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, nil, err
+	}
+	if header[12]>>4 != 2 {
+		return nil, nil, ErrProxyHeader
+	}
+	command, family := header[12]&0xf, header[13]
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, nil, err
+	}
+
+	// LOCAL connections (health checks from the proxy itself) keep the
+	// real addresses
+	if command == 0 {
+		return nil, nil, nil
+	}
+	switch family >> 4 {
+	case 1:
+		if len(payload) < 12 {
+			return nil, nil, ErrProxyHeader
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:]))},
+			&net.TCPAddr{IP: net.IP(payload[4:8]), Port: int(binary.BigEndian.Uint16(payload[10:]))}, nil
+	case 2:
+		if len(payload) < 36 {
+			return nil, nil, ErrProxyHeader
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:]))},
+			&net.TCPAddr{IP: net.IP(payload[16:32]), Port: int(binary.BigEndian.Uint16(payload[34:]))}, nil
+	}
+	return nil, nil, nil
+}
@@ -0,0 +1,100 @@
+package webgo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+)
+
+func proxyV2Header(command, family byte, payload []byte) string {
+	h := append([]byte{}, proxyV2Signature...)
+	h = append(h, 0x20|command, family, 0, 0)
+	binary.BigEndian.PutUint16(h[14:], uint16(len(payload)))
+	return string(append(h, payload...))
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	v4 := []byte{203, 0, 113, 7, 10, 0, 0, 1, 0x30, 0x39, 0x01, 0xbb}
+	for _, tt := range []struct {
+		name         string
+		input        string
+		remote, rest string
+		err          error
+	}{
+		{"NoHeader", "GET / HTTP/1.1\r\n", "", "GET / HTTP/1.1\r\n", nil},
+		{"V1TCP4", "PROXY TCP4 203.0.113.7 10.0.0.1 12345 443\r\nGET", "203.0.113.7:12345", "GET", nil},
+		{"V1TCP6", "PROXY TCP6 2001:db8::7 2001:db8::1 12345 443\r\nGET", "[2001:db8::7]:12345", "GET", nil},
+		{"V1Unknown", "PROXY UNKNOWN\r\nGET", "", "GET", nil},
+		{"V1BadAddress", "PROXY TCP4 nowhere 10.0.0.1 12345 443\r\n", "", "", ErrProxyHeader},
+		{"V1BadPort", "PROXY TCP4 203.0.113.7 10.0.0.1 99999 443\r\n", "", "", ErrProxyHeader},
+		{"V1NoCRLF", "PROXY TCP4 203.0.113.7 10.0.0.1 12345 443\nGET", "", "", ErrProxyHeader},
+		{"V1TooLong", "PROXY TCP4 " + strings.Repeat("1", 120) + "\r\n", "", "", ErrProxyHeader},
+		{"V2TCP4", proxyV2Header(1, 0x11, v4) + "GET", "203.0.113.7:12345", "GET", nil},
+		{"V2Local", proxyV2Header(0, 0, nil) + "GET", "", "GET", nil},
+		{"V2Short", proxyV2Header(1, 0x11, v4[:8]), "", "", ErrProxyHeader},
+		{"V2BadVersion", strings.Replace(proxyV2Header(1, 0x11, v4), "\x21", "\x31", 1), "", "", ErrProxyHeader},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tt.input))
+			remote, _, err := readProxyHeader(r)
+			if err != tt.err {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			got := ""
+			if remote != nil {
+				got = remote.String()
+			}
+			if got != tt.remote {
+				t.Errorf("remote = %q, want %q", got, tt.remote)
+			}
+			rest := make([]byte, 64)
+			n, _ := r.Read(rest)
+			if string(rest[:n]) != tt.rest {
+				t.Errorf("left %q unread, want %q", rest[:n], tt.rest)
+			}
+		})
+	}
+}
+
+func TestProxyListenerTrust(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		trusted string
+		want    string
+	}{
+		{"Trusted", "127.0.0.0/8", "203.0.113.7"},
+		{"Untrusted", "192.0.2.0/24", "127.0.0.1"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+			_, n, _ := net.ParseCIDR(tt.trusted)
+			pl := &proxyListener{Listener: l, trusted: []*net.IPNet{n}}
+
+			go func() {
+				c, err := net.Dial("tcp", l.Addr().String())
+				if err != nil {
+					return
+				}
+				defer c.Close()
+				c.Write([]byte("PROXY TCP4 203.0.113.7 10.0.0.1 12345 443\r\n"))
+			}()
+			c, err := pl.Accept()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			if got := c.RemoteAddr().(*net.TCPAddr).IP.String(); got != tt.want {
+				t.Errorf("RemoteAddr() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
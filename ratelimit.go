@@ -0,0 +1,192 @@
+package webgo
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateKeyFunc names the client a request is counted against, or returns
+// "" to let the next key func decide.
+type RateKeyFunc func(req *Request) string
+
+func KeyByIP(req *Request) string {
+	addr := req.RemoteAddr()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return "ip:" + addr
+}
+
+// KeyBySession counts requests per session, for clients that already
+// have one; the ID itself is not kept in memory.
+func KeyBySession(req *Request) string {
+	token := req.Session().id()
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "session:" + hex.EncodeToString(sum[:16])
+}
+
+// KeyByUser counts requests per authenticated user, as resolved by
+// userID, so users behind one NAT don't share a budget.
+func KeyByUser(userID func(req *Request) string) RateKeyFunc {
+	return func(req *Request) string {
+		if id := userID(req); id != "" {
+			return "user:" + id
+		}
+		return ""
+	}
+}
+
+// RateLimiter is a token bucket per client: each may make Burst requests
+// at once, refilled at Rate per second. Clients are identified by the
+// first of Keys returning a non-empty key, falling back to the IP. At
+// most MaxKeys clients are tracked; beyond that the one seen least
+// recently is forgotten. Clock times both requests and pruning; give it
+// the application's FakeClock in tests.
+type RateLimiter struct {
+	Rate    float64
+	Burst   int
+	Keys    []RateKeyFunc
+	MaxKeys int
+	Clock   Clock
+
+	mu       sync.Mutex
+	buckets  map[string]*list.Element
+	order    *list.List
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+type rateBucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+// rateLimitPruneInterval is how often buckets that have refilled, and so
+// are the same as new ones, are dropped.
+const rateLimitPruneInterval = time.Minute
+
+// NewRateLimiter panics if rate is not positive. Close stops its
+// background pruning.
+func NewRateLimiter(rate float64, burst int, keys ...RateKeyFunc) *RateLimiter {
+	if rate <= 0 || math.IsNaN(rate) {
+		panic(fmt.Sprintf("webgo: invalid rate limit %v per second", rate))
+	}
+	rl := &RateLimiter{
+		Rate:    rate,
+		Burst:   burst,
+		Keys:    keys,
+		MaxKeys: 100000,
+		Clock:   SystemClock,
+		buckets: make(map[string]*list.Element),
+		order:   list.New(),
+		stop:    make(chan struct{}),
+	}
+	go rl.pruneLoop()
+	return rl
+}
+
+func (rl *RateLimiter) Close() error {
+	rl.stopOnce.Do(func() { close(rl.stop) })
+	return nil
+}
+
+func (rl *RateLimiter) key(req *Request) string {
+	for _, key := range rl.Keys {
+		if k := key(req); k != "" {
+			return k
+		}
+	}
+	return KeyByIP(req)
+}
+
+// Allow takes a token for req's client. It returns whether one was
+// available, how many remain and, if none was, when to retry.
+func (rl *RateLimiter) Allow(req *Request) (bool, int, time.Duration) {
+	key := rl.key(req)
+	now := rl.Clock.Now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	var b *rateBucket
+	if el, ok := rl.buckets[key]; ok {
+		rl.order.MoveToFront(el)
+		b = el.Value.(*rateBucket)
+	} else {
+		for rl.MaxKeys > 0 && len(rl.buckets) >= rl.MaxKeys {
+			oldest := rl.order.Back()
+			rl.order.Remove(oldest)
+			delete(rl.buckets, oldest.Value.(*rateBucket).key)
+		}
+		b = &rateBucket{key: key, tokens: float64(rl.Burst), last: now}
+		rl.buckets[key] = rl.order.PushFront(b)
+	}
+	b.tokens = math.Min(float64(rl.Burst), b.tokens+now.Sub(b.last).Seconds()*rl.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rl.Rate * float64(time.Second))
+		return false, 0, wait
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+func (rl *RateLimiter) pruneLoop() {
+	ticker := time.NewTicker(rateLimitPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rl.prune()
+		case <-rl.stop:
+			return
+		}
+	}
+}
+
+// prune drops buckets that have refilled, which are the same as new ones.
+// It walks from the least recently used end and stops at the first one
+// still in use, so it only touches buckets it removes, give or take one.
+func (rl *RateLimiter) prune() {
+	now := rl.Clock.Now()
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for el := rl.order.Back(); el != nil; {
+		b := el.Value.(*rateBucket)
+		if b.tokens+now.Sub(b.last).Seconds()*rl.Rate < float64(rl.Burst) {
+			return
+		}
+		prev := el.Prev()
+		rl.order.Remove(el)
+		delete(rl.buckets, b.key)
+		el = prev
+	}
+}
+
+func (rl *RateLimiter) Middleware(next ProcessFunc) ProcessFunc {
+	return func(req *Request) *Response {
+		ok, remaining, wait := rl.Allow(req)
+		if !ok {
+			resp := TooManyRequests("")
+			resp.SetHeader("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			resp.SetHeader("X-RateLimit-Limit", strconv.Itoa(rl.Burst))
+			resp.SetHeader("X-RateLimit-Remaining", "0")
+			return resp
+		}
+		resp := next(req)
+		resp.SetHeader("X-RateLimit-Limit", strconv.Itoa(rl.Burst))
+		resp.SetHeader("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		return resp
+	}
+}
@@ -0,0 +1,60 @@
+package webgo
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		advance time.Duration
+		prune   bool
+		allowed bool
+	}{
+		{"Exhausted", 0, false, false},
+		{"ExhaustedAfterPrune", 0, true, false},
+		{"Refilled", time.Second, false, true},
+		{"RefilledAfterPrune", time.Second, true, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// far behind real time, as FakeClocks usually are
+			clock := NewFakeClock(time.Time{})
+			rl := NewRateLimiter(1, 2)
+			defer rl.Close()
+			rl.Clock = clock
+
+			req := ParseRequest(httptest.NewRequest("GET", "/", nil))
+			for i := 0; i < 2; i++ {
+				if ok, _, _ := rl.Allow(req); !ok {
+					t.Fatalf("request %d refused within the burst", i+1)
+				}
+			}
+			clock.Advance(tt.advance)
+			if tt.prune {
+				rl.prune()
+			}
+			ok, _, wait := rl.Allow(req)
+			if ok != tt.allowed {
+				t.Errorf("Allow() = %v, want %v", ok, tt.allowed)
+			}
+			if !ok && wait != time.Second {
+				t.Errorf("retry after %v, want 1s", wait)
+			}
+		})
+	}
+}
+
+func TestNewRateLimiterRejectsInvalidRate(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewRateLimiter(%v) didn't panic", rate)
+				}
+			}()
+			NewRateLimiter(rate, 1).Close()
+		}()
+	}
+}
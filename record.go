@@ -0,0 +1,135 @@
+package webgo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type RecordedRequest struct {
+	Time     time.Time   `json:"time"`
+	Method   string      `json:"method"`
+	Path     string      `json:"path"`
+	RawQuery string      `json:"raw_query,omitempty"`
+	Host     string      `json:"host,omitempty"`
+	Headers  http.Header `json:"headers"`
+	Body     []byte      `json:"body,omitempty"`
+}
+
+// Recorder captures a sample of incoming requests as JSON lines, for
+// replaying them later with Replay.
+type Recorder struct {
+	// Rate is the fraction of requests recorded, from 0 to 1.
+	Rate float64
+	// Redact lists headers whose values are replaced before recording,
+	// RedactQuery query parameters.
+	Redact      []string
+	RedactQuery []string
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewRecorder(w io.Writer, rate float64) *Recorder {
+	return &Recorder{
+		Rate:        rate,
+		Redact:      []string{"Authorization", "Cookie", "Proxy-Authorization"},
+		RedactQuery: append([]string(nil), sensitiveQueryParams...),
+		w:           w,
+	}
+}
+
+func (rec *Recorder) Middleware(next ProcessFunc) ProcessFunc {
+	return func(req *Request) *Response {
+		if rec.Rate >= 1 || rand.Float64() < rec.Rate {
+			rec.record(req)
+		}
+		return next(req)
+	}
+}
+
+func (rec *Recorder) record(req *Request) {
+	headers := req.Headers.Clone()
+	for _, name := range rec.Redact {
+		if headers.Get(name) != "" {
+			headers.Set(name, redacted)
+		}
+	}
+	body, err := req.bodyBytes()
+	if err != nil {
+		return
+	}
+	entry := &RecordedRequest{
+		Time:    req.Clock().Now(),
+		Method:  req.Method,
+		Path:    req.Path,
+		Headers: headers,
+		Body:    body,
+	}
+	if req.raw != nil {
+		entry.RawQuery = redactQuery(req.raw.URL.RawQuery, rec.RedactQuery)
+		entry.Host = req.raw.Host
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if _, err := rec.w.Write(append(line, '\n')); err != nil && req.app != nil {
+		req.app.logf("webgo: recording request: %v", err)
+	}
+}
+
+func (r *RecordedRequest) httpRequest(ctx context.Context) (*http.Request, error) {
+	target := r.Path
+	if r.RawQuery != "" {
+		target += "?" + r.RawQuery
+	}
+	hr, err := http.NewRequestWithContext(ctx, r.Method, target, bytes.NewReader(r.Body))
+	if err != nil {
+		return nil, err
+	}
+	hr.Header = r.Headers.Clone()
+	if hr.Header == nil {
+		hr.Header = make(http.Header)
+	}
+	hr.Host = r.Host
+	hr.RequestURI = target
+	hr.RemoteAddr = "replay"
+	return hr, nil
+}
+
+// Replay feeds recorded requests from r through the application in order,
+// calling fn with each request and the response it produced. It stops at
+// the first malformed record or when fn returns false.
+func (app *Application) Replay(ctx context.Context, r io.Reader, fn func(*RecordedRequest, *Response) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var rec RecordedRequest
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return err
+		}
+		hr, err := rec.httpRequest(ctx)
+		if err != nil {
+			return err
+		}
+
+		resp := app.serveBuffered(hr)
+		if fn != nil && !fn(&rec, resp) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
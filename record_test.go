@@ -0,0 +1,47 @@
+package webgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecorderRedacts(t *testing.T) {
+	for _, tt := range []struct {
+		name, query, want string
+	}{
+		{"NoQuery", "", ""},
+		{"Untouched", "page=2&sort=name", "page=2&sort=name"},
+		{"Token", "page=2&token=abc", "page=2&token=" + redacted},
+		{"AnyCase", "Access_Token=abc", "Access_Token=" + redacted},
+		{"Repeated", "secret=a&secret=b", "secret=" + redacted + "&secret=" + redacted},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			rec := NewRecorder(&buf, 1)
+			app := NewApplication()
+			app.Use(rec.Middleware)
+			app.Route("GET /", func(req *Request) *Response { return NoContent() })
+
+			target := "/"
+			if tt.query != "" {
+				target += "?" + tt.query
+			}
+			r := httptest.NewRequest("GET", target, nil)
+			r.Header.Set("Authorization", "Bearer abc")
+			app.ServeHTTP(httptest.NewRecorder(), r)
+
+			var entry RecordedRequest
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatal(err)
+			}
+			if entry.RawQuery != tt.want {
+				t.Errorf("RawQuery = %q, want %q", entry.RawQuery, tt.want)
+			}
+			if got := entry.Headers.Get("Authorization"); got != redacted {
+				t.Errorf("Authorization = %q, want it redacted", got)
+			}
+		})
+	}
+}
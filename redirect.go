@@ -0,0 +1,55 @@
+package webgo
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RedirectToHTTPS returns a handler that permanently redirects every
+// request to the same host, path and query over HTTPS on httpsPort.
+func RedirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		if r.Method == "GET" || r.Method == "HEAD" {
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+		} else {
+			http.Redirect(w, r, target, http.StatusPermanentRedirect)
+		}
+	})
+}
+
+// EnableHTTPSRedirect runs a plaintext listener on httpAddr next to the
+// application's HTTPS listener, redirecting everything to HTTPS. HTTPS
+// responses get a Strict-Transport-Security header when hstsMaxAge is set.
+func (app *Application) EnableHTTPSRedirect(httpAddr string, hstsMaxAge time.Duration) {
+	if hstsMaxAge > 0 {
+		app.hsts = "max-age=" + strconv.Itoa(int(hstsMaxAge.Seconds())) + "; includeSubDomains"
+	}
+	app.OnStart(func(ctx context.Context) error {
+		l, err := app.listen("tcp", httpAddr)
+		if err != nil {
+			return err
+		}
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			port := "443"
+			if addr, ok := app.Addr().(*net.TCPAddr); ok {
+				port = strconv.Itoa(addr.Port)
+			}
+			RedirectToHTTPS(port).ServeHTTP(w, r)
+		})
+		server := app.newServer(handler)
+		server.TLSConfig = nil
+		go server.Serve(app.wrapListener(l))
+		return nil
+	})
+}
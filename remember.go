@@ -0,0 +1,241 @@
+package webgo
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrRememberTokenNotFound = errors.New("remember-me token not found")
+
+// RememberToken is a persistent login as kept by a RememberStore. Series
+// identifies the login on one device and stays fixed; the token changes on
+// every use and only its SHA-256 hash is stored. The previous token's hash
+// is kept with the time it was replaced, for requests that were already
+// on their way with it.
+type RememberToken struct {
+	Series    string
+	TokenHash []byte
+	PrevHash  []byte
+	Rotated   time.Time
+	UserID    string
+	Expires   time.Time
+}
+
+type RememberStore interface {
+	Get(ctx context.Context, series string) (*RememberToken, error)
+	Put(ctx context.Context, t *RememberToken) error
+	Delete(ctx context.Context, series string) error
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// RememberMe logs users back in from a long-lived cookie holding a series
+// and a token. Each use replaces the token; a cookie presenting a known
+// series with a stale token means it was copied, so all of the user's
+// persistent logins are revoked. Its middleware must run inside the
+// Sessions middleware.
+type RememberMe struct {
+	Store      RememberStore
+	CookieName string
+	Path       string
+	Domain     string
+	Secure     bool
+	Lifetime   time.Duration
+
+	// Grace is how long the previous token is still accepted after it has
+	// been replaced, so parallel requests from one browser, sent before
+	// the new cookie arrived, aren't taken for theft.
+	Grace time.Duration
+
+	// OnTheft is called when a stolen token is detected.
+	OnTheft func(req *Request, userID string)
+}
+
+const sessionRememberedKey = "_remembered"
+
+func NewRememberMe(store RememberStore) *RememberMe {
+	return &RememberMe{
+		Store:      store,
+		CookieName: "remember",
+		Path:       "/",
+		Lifetime:   30 * 24 * time.Hour,
+		Grace:      time.Minute,
+	}
+}
+
+func (rm *RememberMe) Middleware(next ProcessFunc) ProcessFunc {
+	return func(req *Request) *Response {
+		c, err := req.Cookie(rm.CookieName)
+		if err != nil || req.Session().UserID() != "" {
+			return next(req)
+		}
+
+		cookie, err := rm.login(req, c.Value)
+		switch {
+		case err == ErrRememberTokenNotFound:
+			cookie = rm.cookie("", -1)
+		case err != nil && req.app != nil:
+			// the store may be back soon: keep the cookie for then
+			req.app.logf("webgo: remember-me login: %v", err)
+		}
+		resp := next(req)
+		if cookie != nil {
+			resp.SetCookie(cookie)
+		}
+		return resp
+	}
+}
+
+// login checks the cookie value and, if it's valid, logs its user in and
+// returns the cookie with the next token. A token replaced within Grace
+// logs the user in without a new cookie: the request that replaced it
+// sends that. ErrRememberTokenNotFound means the cookie should go.
+func (rm *RememberMe) login(req *Request, value string) (*http.Cookie, error) {
+	series, token, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, ErrRememberTokenNotFound
+	}
+	ctx := req.Context()
+	t, err := rm.Store.Get(ctx, series)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Clock().Now().Before(t.Expires) {
+		rm.Store.Delete(ctx, series)
+		return nil, ErrRememberTokenNotFound
+	}
+	hash := hashRememberToken(token)
+	if subtle.ConstantTimeCompare(hash, t.TokenHash) != 1 {
+		if subtle.ConstantTimeCompare(hash, t.PrevHash) == 1 && req.Clock().Since(t.Rotated) < rm.Grace {
+			rm.logIn(req, t)
+			return nil, nil
+		}
+		// someone else already used this cookie's token
+		if rm.OnTheft != nil {
+			rm.OnTheft(req, t.UserID)
+		}
+		if err := rm.Store.DeleteUser(ctx, t.UserID); err != nil && req.app != nil {
+			req.app.logf("webgo: revoking persistent logins of %s: %v", t.UserID, err)
+		}
+		return nil, ErrRememberTokenNotFound
+	}
+
+	token = newSessionID()
+	t.PrevHash, t.Rotated = t.TokenHash, req.Clock().Now()
+	t.TokenHash = hashRememberToken(token)
+	if err := rm.Store.Put(ctx, t); err != nil {
+		return nil, err
+	}
+	rm.logIn(req, t)
+	return rm.cookie(series+":"+token, int(t.Expires.Sub(req.Clock().Now())/time.Second)), nil
+}
+
+func (rm *RememberMe) logIn(req *Request, t *RememberToken) {
+	sess := req.Session()
+	sess.Login(t.UserID)
+	sess.Set(sessionRememberedKey, true)
+}
+
+// Remember starts a persistent login for userID, typically right after a
+// password login where the user ticked "remember me".
+func (rm *RememberMe) Remember(req *Request, resp *Response, userID string) error {
+	series, token := newSessionID(), newSessionID()
+	t := &RememberToken{
+		Series:    series,
+		TokenHash: hashRememberToken(token),
+		UserID:    userID,
+		Expires:   req.Clock().Now().Add(rm.Lifetime),
+	}
+	if err := rm.Store.Put(req.Context(), t); err != nil {
+		return err
+	}
+	resp.SetCookie(rm.cookie(series+":"+token, int(rm.Lifetime/time.Second)))
+	return nil
+}
+
+// Forget ends the persistent login of this device, e.g. on logout.
+func (rm *RememberMe) Forget(req *Request, resp *Response) error {
+	resp.SetCookie(rm.cookie("", -1))
+	c, err := req.Cookie(rm.CookieName)
+	if err != nil {
+		return nil
+	}
+	series, _, _ := strings.Cut(c.Value, ":")
+	return rm.Store.Delete(req.Context(), series)
+}
+
+// Remembered reports whether the session's user was logged in from a
+// remember-me cookie rather than with their credentials, so sensitive
+// actions can ask for the password again.
+func (sess *Session) Remembered() bool {
+	return sess.GetBool(sessionRememberedKey)
+}
+
+func (rm *RememberMe) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     rm.CookieName,
+		Value:    value,
+		Path:     rm.Path,
+		Domain:   rm.Domain,
+		Secure:   rm.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   maxAge,
+	}
+}
+
+func hashRememberToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// MemoryRememberStore is a RememberStore for tests and single-instance
+// deployments; persistent logins don't survive a restart with it.
+type MemoryRememberStore struct {
+	mu     sync.Mutex
+	tokens map[string]RememberToken
+}
+
+func NewMemoryRememberStore() *MemoryRememberStore {
+	return &MemoryRememberStore{tokens: make(map[string]RememberToken)}
+}
+
+func (ms *MemoryRememberStore) Get(ctx context.Context, series string) (*RememberToken, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	t, ok := ms.tokens[series]
+	if !ok {
+		return nil, ErrRememberTokenNotFound
+	}
+	return &t, nil
+}
+
+func (ms *MemoryRememberStore) Put(ctx context.Context, t *RememberToken) error {
+	ms.mu.Lock()
+	ms.tokens[t.Series] = *t
+	ms.mu.Unlock()
+	return nil
+}
+
+func (ms *MemoryRememberStore) Delete(ctx context.Context, series string) error {
+	ms.mu.Lock()
+	delete(ms.tokens, series)
+	ms.mu.Unlock()
+	return nil
+}
+
+func (ms *MemoryRememberStore) DeleteUser(ctx context.Context, userID string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for series, t := range ms.tokens {
+		if t.UserID == userID {
+			delete(ms.tokens, series)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package webgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func rememberApp(clock Clock, rm *RememberMe) *Application {
+	app := NewApplication(WithClock(clock))
+	app.Use(NewSessions(NewMemoryStore(0, 0)).Middleware, rm.Middleware)
+	app.Route("/login", func(req *Request) *Response {
+		resp := Text(200, "ok")
+		if err := rm.Remember(req, resp, "alice"); err != nil {
+			return Text(500, err.Error())
+		}
+		return resp
+	})
+	app.Route("/me", func(req *Request) *Response {
+		return Text(200, req.Session().UserID())
+	})
+	return app
+}
+
+func rememberGet(app *Application, path string, cookie *http.Cookie) (string, *http.Cookie) {
+	r := httptest.NewRequest("GET", path, nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "remember" {
+			return strings.TrimSpace(w.Body.String()), c
+		}
+	}
+	return strings.TrimSpace(w.Body.String()), nil
+}
+
+func TestRememberMe(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		value   string        // replaces the issued cookie value if set
+		rotate  bool          // use the cookie once before presenting it again
+		advance time.Duration // between issuing or rotating and presenting
+		user    string
+		cookie  string // "new", "none" or "cleared"
+		theft   bool
+	}{
+		{name: "Valid", user: "alice", cookie: "new"},
+		{name: "Expired", advance: 31 * 24 * time.Hour, cookie: "cleared"},
+		{name: "UnknownSeries", value: "bogus:token", cookie: "cleared"},
+		{name: "Malformed", value: "bogus", cookie: "cleared"},
+		{name: "ReplayWithinGrace", rotate: true, advance: 10 * time.Second, user: "alice", cookie: "none"},
+		{name: "ReplayAfterGrace", rotate: true, advance: 2 * time.Minute, cookie: "cleared", theft: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewFakeClock(time.Time{})
+			rm := NewRememberMe(NewMemoryRememberStore())
+			theft := ""
+			rm.OnTheft = func(req *Request, userID string) { theft = userID }
+			app := rememberApp(clock, rm)
+
+			_, issued := rememberGet(app, "/login", nil)
+			if issued == nil {
+				t.Fatal("login set no remember cookie")
+			}
+			presented := *issued
+			if tt.value != "" {
+				presented.Value = tt.value
+			}
+			var rotated *http.Cookie
+			if tt.rotate {
+				if _, rotated = rememberGet(app, "/me", issued); rotated == nil {
+					t.Fatal("first use set no new remember cookie")
+				}
+			}
+			clock.Advance(tt.advance)
+
+			user, cookie := rememberGet(app, "/me", &presented)
+			if user != tt.user {
+				t.Errorf("user = %q, want %q", user, tt.user)
+			}
+			switch {
+			case tt.cookie == "none" && cookie != nil:
+				t.Errorf("set cookie %q, want none", cookie.Value)
+			case tt.cookie == "cleared" && (cookie == nil || cookie.MaxAge >= 0):
+				t.Errorf("cookie = %v, want it cleared", cookie)
+			case tt.cookie == "new" && (cookie == nil || cookie.Value == issued.Value || cookie.MaxAge <= 0):
+				t.Errorf("cookie = %v, want a new token", cookie)
+			}
+			if got := theft != ""; got != tt.theft {
+				t.Errorf("theft reported = %v, want %v", got, tt.theft)
+			}
+			if tt.theft {
+				// every persistent login of the user is revoked
+				if user, _ := rememberGet(app, "/me", rotated); user != "" {
+					t.Errorf("rotated cookie still logs in %q after theft", user)
+				}
+			}
+		})
+	}
+}
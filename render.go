@@ -0,0 +1,136 @@
+package webgo
+
+import (
+	"bytes"
+	"html/template"
+	"io/fs"
+	"sync"
+)
+
+type Injector func(req *Request, data map[string]interface{})
+
+type templateSource struct {
+	fsys     fs.FS
+	patterns []string
+}
+
+// Renderer renders html/template views. FuncMaps, delimiters and sources
+// may be changed at any time; templates are (re)parsed on the next Render.
+type Renderer struct {
+	mu        sync.Mutex
+	funcs     template.FuncMap
+	left      string
+	right     string
+	sources   []templateSource
+	injectors []Injector
+	tmpl      *template.Template
+}
+
+func NewRenderer() *Renderer {
+	return &Renderer{
+		funcs: make(template.FuncMap),
+	}
+}
+
+func (r *Renderer) Funcs(funcs template.FuncMap) *Renderer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, fn := range funcs {
+		r.funcs[name] = fn
+	}
+	r.tmpl = nil
+	return r
+}
+
+func (r *Renderer) Delims(left, right string) *Renderer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.left, r.right = left, right
+	r.tmpl = nil
+	return r
+}
+
+// Inject registers fn to add values (CSRF token, current user...) to the
+// data of every render.
+func (r *Renderer) Inject(fn Injector) *Renderer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.injectors = append(r.injectors, fn)
+	return r
+}
+
+func (r *Renderer) ParseGlob(pattern string) *Renderer {
+	return r.ParseFS(nil, pattern)
+}
+
+// ParseFS adds templates matching patterns in fsys; a nil fsys means the
+// OS file system.
+func (r *Renderer) ParseFS(fsys fs.FS, patterns ...string) *Renderer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sources = append(r.sources, templateSource{fsys, patterns})
+	r.tmpl = nil
+	return r
+}
+
+// Reload drops the parsed templates so they are read again on next use.
+func (r *Renderer) Reload() {
+	r.mu.Lock()
+	r.tmpl = nil
+	r.mu.Unlock()
+}
+
+func (r *Renderer) templates() (*template.Template, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tmpl != nil {
+		return r.tmpl, nil
+	}
+
+	tmpl := template.New("").Delims(r.left, r.right).Funcs(r.funcs)
+	for _, src := range r.sources {
+		var err error
+		if src.fsys == nil {
+			for _, pattern := range src.patterns {
+				if tmpl, err = tmpl.ParseGlob(pattern); err != nil {
+					return nil, err
+				}
+			}
+		} else if tmpl, err = tmpl.ParseFS(src.fsys, src.patterns...); err != nil {
+			return nil, err
+		}
+	}
+	r.tmpl = tmpl
+	return tmpl, nil
+}
+
+// Render executes the named template. Templates receive a map holding the
+// injected values; data is merged into it when it is a
+// map[string]interface{}, and is available as .Data otherwise.
+func (r *Renderer) Render(req *Request, status int, name string, data interface{}) *Response {
+	tmpl, err := r.templates()
+	if err != nil {
+		return InternalError(err)
+	}
+
+	values := make(map[string]interface{})
+	r.mu.Lock()
+	injectors := r.injectors
+	r.mu.Unlock()
+	for _, inject := range injectors {
+		inject(req, values)
+	}
+	if m, ok := data.(map[string]interface{}); ok {
+		for k, v := range m {
+			values[k] = v
+		}
+	} else if data != nil {
+		values["Data"] = data
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, values); err != nil {
+		return InternalError(err)
+	}
+	return HTML(status, buf.String())
+}
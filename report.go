@@ -0,0 +1,34 @@
+package webgo
+
+type PanicHook func(req *Request, recovered interface{}, stack []byte)
+
+type ErrorHook func(req *Request, err error)
+
+// OnPanic registers a hook called with the recovered value and stack trace
+// whenever a handler panics. It isn't called under WithoutRecovery.
+func (app *Application) OnPanic(hook PanicHook) {
+	app.panicHooks = append(app.panicHooks, hook)
+}
+
+// OnError registers a hook called for every 5xx response carrying an
+// error, whether returned by a RouteE handler, passed to InternalError or
+// recovered from a panic.
+func (app *Application) OnError(hook ErrorHook) {
+	app.errorHooks = append(app.errorHooks, hook)
+}
+
+func (app *Application) reportPanic(req *Request, recovered interface{}, stack []byte) {
+	for _, hook := range app.panicHooks {
+		hook(req, recovered, stack)
+	}
+}
+
+func (app *Application) reportError(req *Request, resp *Response) {
+	if resp.err == nil || resp.Status < 500 {
+		return
+	}
+	app.logf("webgo: %s %s: %v", req.Method, req.Path, resp.err)
+	for _, hook := range app.errorHooks {
+		hook(req, resp.err)
+	}
+}
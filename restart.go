@@ -0,0 +1,126 @@
+//go:build !windows
+
+package webgo
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type fileListener interface {
+	File() (*os.File, error)
+}
+
+// Restart starts a new copy of the running binary that inherits all of the
+// application's listeners, then drains this process with Shutdown. No
+// connection is refused while the binary is swapped.
+func (app *Application) Restart(ctx context.Context) error {
+	app.mu.Lock()
+	listeners := append([]net.Listener(nil), app.listeners...)
+	app.mu.Unlock()
+	if len(listeners) == 0 {
+		return errors.New("webgo: no listeners to hand over")
+	}
+
+	files := make([]*os.File, 0, len(listeners))
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, l := range listeners {
+		fl, ok := l.(fileListener)
+		if !ok {
+			return errors.New("webgo: listener " + l.Addr().String() + " can't be handed over")
+		}
+		f, err := fl.File()
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), inheritFdsEnv+"="+strconv.Itoa(len(files)))
+	cmd.ExtraFiles = files
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// the child owns the socket files now
+	for _, l := range listeners {
+		if ul, ok := l.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+	return app.Shutdown(ctx)
+}
+
+// RestartOnSignal performs a Restart when SIGUSR2 arrives, giving the old
+// process up to drainTimeout to finish its in-flight requests.
+func (app *Application) RestartOnSignal(drainTimeout time.Duration) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR2)
+
+	go func() {
+		for range ch {
+			ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+			err := app.Restart(ctx)
+			cancel()
+			if err == nil {
+				signal.Stop(ch)
+				return
+			}
+			app.logf("webgo: restart failed: %v", err)
+		}
+	}()
+}
+
+// RebuildAndRestart returns a LiveReload OnChange func that, when a .go
+// file changed, runs build (by default "go build -o <this binary> .") and
+// restarts into the result. A failed build leaves the old process serving.
+func (app *Application) RebuildAndRestart(build ...string) func(changed []string) error {
+	return func(changed []string) error {
+		rebuild := false
+		for _, path := range changed {
+			if strings.HasSuffix(path, ".go") {
+				rebuild = true
+				break
+			}
+		}
+		if !rebuild {
+			return nil
+		}
+
+		args := build
+		if len(args) == 0 {
+			executable, err := os.Executable()
+			if err != nil {
+				return err
+			}
+			args = []string{"go", "build", "-o", executable, "."}
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return app.Restart(ctx)
+	}
+}
@@ -0,0 +1,117 @@
+package webgo
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RewriteFunc changes a request before it is routed. Rewrites registered
+// with Application.Rewrite run in registration order.
+type RewriteFunc func(req *Request)
+
+// Rewrite registers a rewrite applied to every request ahead of routing,
+// so routes can be written without knowing how an ingress or outer mux
+// mangled the path.
+func (app *Application) Rewrite(rewrites ...RewriteFunc) {
+	app.rewrites = append(app.rewrites, rewrites...)
+}
+
+func (app *Application) rewrite(req *Request) {
+	for _, rw := range app.rewrites {
+		rw(req)
+	}
+}
+
+// TrimPrefix is a rewrite removing prefix from request paths, for an
+// application mounted under a sub-path. Paths outside prefix are left
+// alone. The removed part is available from Request.BasePath.
+func TrimPrefix(prefix string) RewriteFunc {
+	prefix = strings.TrimRight(prefix, "/")
+	return func(req *Request) {
+		stripPrefix(req, prefix)
+	}
+}
+
+// RewritePath is a rewrite replacing matches of pattern in request paths
+// with replacement, which may refer to submatches as in
+// regexp.Regexp.ReplaceAllString.
+func RewritePath(pattern, replacement string) RewriteFunc {
+	re := regexp.MustCompile(pattern)
+	return func(req *Request) {
+		if path := re.ReplaceAllString(req.Path, replacement); path != req.Path {
+			setRequestPath(req, path, "")
+		}
+	}
+}
+
+// RewriteHost is a rewrite replacing matches of pattern in the request's
+// Host with replacement.
+func RewriteHost(pattern, replacement string) RewriteFunc {
+	re := regexp.MustCompile(pattern)
+	return func(req *Request) {
+		if req.raw == nil {
+			return
+		}
+		if host := re.ReplaceAllString(req.raw.Host, replacement); host != req.raw.Host {
+			r := *req.raw
+			r.Host = host
+			req.raw = &r
+		}
+	}
+}
+
+// StripPrefix serves requests below prefix with proc, as if prefix wasn't
+// part of the path, and answers others with 404. It is meant for routes
+// handing a whole subtree to code written for the root:
+//
+//	app.Route("/admin(/.*)?", webgo.StripPrefix("/admin", admin.Process))
+func StripPrefix(prefix string, proc ProcessFunc) ProcessFunc {
+	prefix = strings.TrimRight(prefix, "/")
+	return func(req *Request) *Response {
+		if !stripPrefix(req, prefix) {
+			return NotFound("")
+		}
+		return proc(req)
+	}
+}
+
+// BasePath returns the path prefixes stripped from the request by
+// TrimPrefix and StripPrefix, for building links that work from outside.
+func (req *Request) BasePath() string {
+	return req.basePath
+}
+
+func stripPrefix(req *Request, prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	if req.Path != prefix && !strings.HasPrefix(req.Path, prefix+"/") {
+		return false
+	}
+	rawPath := ""
+	if req.raw != nil && req.raw.URL.RawPath != "" {
+		rawPath = strings.TrimPrefix(req.raw.URL.RawPath, prefix)
+		if rawPath == req.raw.URL.RawPath {
+			// the prefix is escaped differently; let net/url re-escape
+			rawPath = ""
+		}
+	}
+	setRequestPath(req, "/"+strings.TrimLeft(req.Path[len(prefix):], "/"), rawPath)
+	req.basePath += prefix
+	return true
+}
+
+// setRequestPath changes the path seen by routing and by handlers reading
+// the underlying http.Request, such as mounted ones.
+func setRequestPath(req *Request, path, rawPath string) {
+	req.Path = path
+	if req.raw == nil {
+		return
+	}
+	r := *req.raw
+	u := *r.URL
+	u.Path, u.RawPath = path, rawPath
+	r.URL = &u
+	r.RequestURI = u.RequestURI()
+	req.raw = &r
+}
@@ -0,0 +1,161 @@
+package webgo
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strings"
+)
+
+const maxRouteSamples = 32
+
+// routeSamples generates strings matched by re: the shortest ones plus a
+// few variants taking other alternatives and repetition counts.
+func routeSamples(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}
+	case syntax.OpCharClass:
+		var out []string
+		for i := 0; i+1 < len(re.Rune) && len(out) < 3; i += 2 {
+			lo, hi := re.Rune[i], re.Rune[i+1]
+			if lo == '\n' && lo < hi {
+				lo++
+			}
+			out = append(out, string(lo))
+			if hi != lo && hi < 0x7f {
+				out = append(out, string(hi))
+			}
+		}
+		return out
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return []string{"x", "/"}
+	case syntax.OpCapture:
+		return routeSamples(re.Sub[0])
+	case syntax.OpAlternate:
+		var out []string
+		for _, sub := range re.Sub {
+			out = append(out, routeSamples(sub)...)
+		}
+		return capSamples(out)
+	case syntax.OpConcat:
+		out := []string{""}
+		for _, sub := range re.Sub {
+			subSamples := routeSamples(sub)
+			next := make([]string, 0, len(out)*len(subSamples))
+			for _, prefix := range out {
+				for _, s := range subSamples {
+					next = append(next, prefix+s)
+				}
+			}
+			out = capSamples(next)
+		}
+		return out
+	case syntax.OpStar, syntax.OpQuest:
+		return capSamples(append([]string{""}, routeSamples(re.Sub[0])...))
+	case syntax.OpPlus:
+		one := routeSamples(re.Sub[0])
+		out := append([]string(nil), one...)
+		for _, s := range one {
+			out = append(out, s+s)
+		}
+		return capSamples(out)
+	case syntax.OpRepeat:
+		sub := routeSamples(re.Sub[0])
+		var out []string
+		for _, s := range sub {
+			out = append(out, strings.Repeat(s, re.Min))
+			if re.Max != re.Min {
+				out = append(out, strings.Repeat(s, re.Min+1))
+			}
+		}
+		return capSamples(out)
+	}
+	// empty matches, anchors and word boundaries consume nothing
+	return []string{""}
+}
+
+func capSamples(samples []string) []string {
+	seen := make(map[string]bool, len(samples))
+	out := samples[:0]
+	for _, s := range samples {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	if len(out) > maxRouteSamples {
+		out = out[:maxRouteSamples]
+	}
+	return out
+}
+
+// RouteConflict reports a route whose requests are, at least partly,
+// dispatched to an earlier route. Examples are "METHOD path" strings; for
+// routes without a method the method part may be empty.
+type RouteConflict struct {
+	Route    string
+	Earlier  string
+	Shadowed bool
+	Examples []string
+}
+
+func (c *RouteConflict) String() string {
+	if c.Shadowed {
+		return fmt.Sprintf("route %q can never match: %q matches everything it does (e.g. %q)", c.Route, c.Earlier, strings.TrimSpace(c.Examples[0]))
+	}
+	return fmt.Sprintf("route %q overlaps earlier route %q, which wins for e.g. %q", c.Route, c.Earlier, strings.TrimSpace(c.Examples[0]))
+}
+
+// CheckRoutes looks for routes that are hidden by earlier ones under
+// first-match dispatch. It tries sample requests derived from each route's
+// pattern against all routes registered before it: a route is reported as
+// shadowed when an earlier route takes every sample, and as overlapping
+// when it takes only some. Sampling is a heuristic; an empty result is not
+// a proof that no conflicts exist.
+func (app *Application) CheckRoutes() []*RouteConflict {
+	var conflicts []*RouteConflict
+	processors := app.table().processors
+	for j, later := range processors {
+		re := app.routeRegexp(later)
+		if re == nil {
+			continue
+		}
+		parsed, err := syntax.Parse(re.String(), syntax.Perl)
+		if err != nil {
+			continue
+		}
+		var samples []string
+		for _, s := range routeSamples(parsed.Simplify()) {
+			if re.MatchString(s) {
+				samples = append(samples, s)
+			}
+		}
+		if len(samples) == 0 {
+			continue
+		}
+
+		stolen := make(map[string]bool)
+		for i := 0; i < j; i++ {
+			earlier := processors[i]
+			var examples []string
+			for _, s := range samples {
+				if stolen[s] {
+					continue
+				}
+				if ok, _ := earlier.Match(s); ok {
+					stolen[s] = true
+					examples = append(examples, s)
+				}
+			}
+			if len(examples) > 0 {
+				conflicts = append(conflicts, &RouteConflict{
+					Route:    later.Pattern,
+					Earlier:  earlier.Pattern,
+					Shadowed: len(examples) == len(samples),
+					Examples: examples,
+				})
+			}
+		}
+	}
+	return conflicts
+}
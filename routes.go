@@ -0,0 +1,161 @@
+package webgo
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo describes a registered route for documentation and audits.
+// Middleware lists the application-wide middleware followed by the
+// route's own, outermost first.
+type RouteInfo struct {
+	Method     string   `json:"method"`
+	Pattern    string   `json:"pattern"`
+	Name       string   `json:"name,omitempty"`
+	Middleware []string `json:"middleware,omitempty"`
+	Handler    string   `json:"handler"`
+	Location   string   `json:"location,omitempty"`
+}
+
+// Named sets the route's name, shown by PrintRoutes and the exports.
+func (p *Processor) Named(name string) *Processor {
+	p.Name = name
+	return p
+}
+
+// RouteOption configures a route before Route publishes it. Routes added
+// while serving must be set up with options: the chained methods, like
+// Use, change a route that may already be handling requests.
+type RouteOption func(*Processor)
+
+// WithRouteMiddleware is Processor.Use as an option, so guards are in
+// place before the route is reachable.
+func WithRouteMiddleware(mws ...Middleware) RouteOption {
+	return func(p *Processor) {
+		p.Use(mws...)
+	}
+}
+
+func WithStreamBody() RouteOption {
+	return func(p *Processor) {
+		p.StreamBody()
+	}
+}
+
+func WithRouteDoc(doc RouteDoc) RouteOption {
+	return func(p *Processor) {
+		p.Describe(doc)
+	}
+}
+
+func WithRouteName(name string) RouteOption {
+	return func(p *Processor) {
+		p.Named(name)
+	}
+}
+
+// Routes lists the routes in matching order.
+func (app *Application) Routes() []RouteInfo {
+	var global []string
+	for _, mw := range app.middleware {
+		global = append(global, funcName(mw))
+	}
+
+	routes := make([]RouteInfo, 0, len(app.table().processors))
+	for _, p := range app.table().processors {
+		method, pattern := "*", p.Pattern
+		if i := strings.IndexByte(pattern, ' '); i >= 0 {
+			method, pattern = pattern[:i], pattern[i+1:]
+			if method == ".*" {
+				method = "*"
+			}
+		}
+		info := RouteInfo{
+			Method:     method,
+			Pattern:    pattern,
+			Name:       p.Name,
+			Middleware: append([]string(nil), global...),
+		}
+		for _, mw := range p.middleware {
+			info.Middleware = append(info.Middleware, funcName(mw))
+		}
+
+		var handler interface{} = p.handler
+		if p.handler == nil {
+			handler = p.Process
+		}
+		info.Handler = funcName(handler)
+		info.Location = funcLocation(handler)
+		routes = append(routes, info)
+	}
+	return routes
+}
+
+func (app *Application) PrintRoutes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tNAME\tHANDLER\tMIDDLEWARE")
+	for _, r := range app.Routes() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Method, r.Pattern, r.Name, r.Handler, strings.Join(r.Middleware, ", "))
+	}
+	return tw.Flush()
+}
+
+func (app *Application) WriteRoutesJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(app.Routes())
+}
+
+// WriteRoutesCSV writes one row per route; middleware names are joined
+// with "|".
+func (app *Application) WriteRoutesCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"method", "pattern", "name", "middleware", "handler", "location"})
+	for _, r := range app.Routes() {
+		cw.Write([]string{r.Method, r.Pattern, r.Name, strings.Join(r.Middleware, "|"), r.Handler, r.Location})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func funcForValue(fn interface{}) *runtime.Func {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil
+	}
+	return runtime.FuncForPC(v.Pointer())
+}
+
+// funcName returns the function's name without its package's directory,
+// e.g. "webgo.(*Metrics).Middleware".
+func funcName(fn interface{}) string {
+	f := funcForValue(fn)
+	if f == nil {
+		return ""
+	}
+	name := f.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimSuffix(name, "-fm")
+}
+
+func funcLocation(fn interface{}) string {
+	f := funcForValue(fn)
+	if f == nil {
+		return ""
+	}
+	file, line := f.FileLine(f.Entry())
+	if strings.HasPrefix(file, "<") {
+		// method values are wrappers without a source position
+		return ""
+	}
+	return file + ":" + strconv.Itoa(line)
+}
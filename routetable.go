@@ -0,0 +1,194 @@
+package webgo
+
+import (
+	"regexp"
+	"strings"
+)
+
+// routeTable is an immutable snapshot of the registered processors.
+// ServeHTTP loads the current one without locking; registration copies
+// it, changes the copy and publishes that, so routes can be added while
+// requests are being served.
+type routeTable struct {
+	processors []*Processor
+	fallback   *Processor
+
+	// for each method, the processors that may take it in registration
+	// order, so dispatch only looks at those; anyMethod serves methods
+	// no route names
+	byMethod  map[string][]*Processor
+	anyMethod []*Processor
+}
+
+var emptyRouteTable = &routeTable{}
+
+func (app *Application) table() *routeTable {
+	if t := app.routes.Load(); t != nil {
+		return t
+	}
+	return emptyRouteTable
+}
+
+// updateRoutes publishes a changed copy of the route table.
+func (app *Application) updateRoutes(change func(t *routeTable)) {
+	app.routesMu.Lock()
+	defer app.routesMu.Unlock()
+	old := app.table()
+	t := &routeTable{
+		processors: clip(old.processors),
+		fallback:   old.fallback,
+		byMethod:   make(map[string][]*Processor, len(old.byMethod)),
+		anyMethod:  clip(old.anyMethod),
+	}
+	for m, ps := range old.byMethod {
+		t.byMethod[m] = clip(ps)
+	}
+	change(t)
+	app.routes.Store(t)
+}
+
+// clip limits the capacity of s to its length, so appending to it copies
+// instead of writing to an array an older snapshot still uses.
+func clip(s []*Processor) []*Processor {
+	return s[:len(s):len(s)]
+}
+
+func (app *Application) SetDefaultProcessor(p *Processor) {
+	app.updateRoutes(func(t *routeTable) {
+		t.fallback = p
+	})
+}
+
+func (app *Application) AddProcessor(p *Processor) {
+	app.updateRoutes(func(t *routeTable) {
+		t.processors = append(t.processors, p)
+		for _, m := range p.methods {
+			if _, ok := t.byMethod[m]; !ok {
+				t.byMethod[m] = clip(t.anyMethod)
+			}
+			t.byMethod[m] = append(t.byMethod[m], p)
+		}
+		if p.methods == nil {
+			t.anyMethod = append(t.anyMethod, p)
+			for m := range t.byMethod {
+				t.byMethod[m] = append(t.byMethod[m], p)
+			}
+		}
+	})
+}
+
+// lookup returns the first processor handling method and path, or the
+// fallback, with the arguments appended to args.
+func (t *routeTable) lookup(method, path string, args []string) (*Processor, []string) {
+	for _, p := range t.candidates(method) {
+		if ok, matched := p.match(method, path, args); ok {
+			return p, matched
+		}
+	}
+	return t.fallback, args
+}
+
+// candidates returns the processors that may handle method, in order.
+func (t *routeTable) candidates(method string) []*Processor {
+	if ps, ok := t.byMethod[method]; ok {
+		return ps
+	}
+	return t.anyMethod
+}
+
+// routeSegment is one slash-separated part of a route's path: literal
+// text, or a parameter matching what ([^/]+) would.
+type routeSegment struct {
+	literal string
+	param   bool
+}
+
+const segmentParam = "([^/]+)"
+
+// routeSegments splits path into segments if it is made of nothing but
+// literal text and ([^/]+) parameters, as most routes are, so they can be
+// matched without running the regular expression. It returns nil for any
+// other path.
+func routeSegments(path string) []routeSegment {
+	var segments []routeSegment
+	for {
+		var seg routeSegment
+		if strings.HasPrefix(path, segmentParam) {
+			seg.param = true
+			path = path[len(segmentParam):]
+		} else {
+			j := strings.IndexByte(path, '/')
+			if j < 0 {
+				j = len(path)
+			}
+			seg.literal, path = path[:j], path[j:]
+			if regexp.QuoteMeta(seg.literal) != seg.literal {
+				return nil
+			}
+		}
+		segments = append(segments, seg)
+		if path == "" {
+			return segments
+		}
+		if path[0] != '/' {
+			return nil
+		}
+		path = path[1:]
+	}
+}
+
+// matchSegments matches path against segments, appending the parameters
+// to args. It allocates nothing unless args has to grow.
+func matchSegments(segments []routeSegment, path string, args []string) (bool, []string) {
+	last := len(segments) - 1
+	for i, seg := range segments {
+		part := path
+		if j := strings.IndexByte(path, '/'); j >= 0 {
+			if i == last {
+				return false, nil
+			}
+			part, path = path[:j], path[j+1:]
+		} else if i < last {
+			return false, nil
+		}
+		if seg.param {
+			if part == "" {
+				return false, nil
+			}
+			args = append(args, part)
+		} else if part != seg.literal {
+			return false, nil
+		}
+	}
+	return true, args
+}
+
+type compiledRegexp struct {
+	re  *regexp.Regexp
+	err error
+}
+
+// compileRegexp compiles expr once per application: routes generated from
+// configuration tend to repeat method and path patterns, and a Regexp is
+// safe to share between them.
+func (app *Application) compileRegexp(expr string) (*regexp.Regexp, error) {
+	c, ok := app.regexps.Load(expr)
+	if !ok {
+		re, err := regexp.Compile(expr)
+		c, _ = app.regexps.LoadOrStore(expr, &compiledRegexp{re, err})
+	}
+	compiled := c.(*compiledRegexp)
+	return compiled.re, compiled.err
+}
+
+// routeRegexp returns the regexp matching a route's whole "METHOD path"
+// pattern, or nil for processors built by hand. Dispatch doesn't need it,
+// so it is only compiled when first asked for.
+func (app *Application) routeRegexp(p *Processor) *regexp.Regexp {
+	p.reOnce.Do(func() {
+		if p.expr != "" {
+			p.re, _ = app.compileRegexp(p.expr)
+		}
+	})
+	return p.re
+}
@@ -0,0 +1,107 @@
+package webgo
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func benchmarkApp() *Application {
+	app := NewApplication()
+	handler := func(req *Request) *Response { return NoContent() }
+	app.Route("GET /", handler)
+	app.Route("GET /about", handler)
+	app.Route("POST /users", handler)
+	app.Route("GET /users/([^/]+)", handler)
+	app.Route("/health", handler)
+	app.Route(`GET /posts/(\d+)`, handler)
+	return app
+}
+
+var lookups = []struct {
+	name, method, path string
+	args               int
+}{
+	{"Static", "GET", "/about", 0},
+	{"Param", "GET", "/users/42", 1},
+	{"AnyMethod", "HEAD", "/health", 0},
+}
+
+func TestLookupDoesNotAllocate(t *testing.T) {
+	routes := benchmarkApp().table()
+	for _, l := range lookups {
+		var buf [8]string
+		p, args := routes.lookup(l.method, l.path, buf[:0])
+		if p == nil || len(args) != l.args {
+			t.Fatalf("%s %s: got %v, %v", l.method, l.path, p, args)
+		}
+		allocs := testing.AllocsPerRun(100, func() {
+			routes.lookup(l.method, l.path, buf[:0])
+		})
+		if allocs != 0 {
+			t.Errorf("%s %s: %v allocations per lookup", l.method, l.path, allocs)
+		}
+	}
+}
+
+func BenchmarkLookup(b *testing.B) {
+	routes := benchmarkApp().table()
+	for _, l := range lookups {
+		b.Run(l.name, func(b *testing.B) {
+			var buf [8]string
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				routes.lookup(l.method, l.path, buf[:0])
+			}
+		})
+	}
+}
+
+func BenchmarkLookupRegexp(b *testing.B) {
+	routes := benchmarkApp().table()
+	var buf [8]string
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		routes.lookup("GET", "/posts/7", buf[:0])
+	}
+}
+
+func TestRouteAddedWhileServingIsGuarded(t *testing.T) {
+	app := NewApplication()
+	guard := func(next ProcessFunc) ProcessFunc {
+		return func(req *Request) *Response {
+			return Forbidden("")
+		}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest("GET", "/admin", nil))
+			if w.Code != 404 && w.Code != 403 {
+				t.Errorf("GET /admin answered %d", w.Code)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		app.Route("GET /admin", func(req *Request) *Response {
+			return Text(200, "secret")
+		}, WithRouteMiddleware(guard), WithRouteDoc(RouteDoc{Hidden: true}))
+	}
+	close(stop)
+	<-done
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/admin", nil))
+	if w.Code != 403 {
+		t.Errorf("GET /admin answered %d, want 403", w.Code)
+	}
+}
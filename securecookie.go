@@ -0,0 +1,188 @@
+package webgo
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrCookieTooLarge = errors.New("encoded cookie exceeds 4096 bytes")
+	ErrCookieExpired  = errors.New("cookie expired")
+)
+
+// CookieKey is one generation of cookie keys. Hash signs values with
+// HMAC-SHA256 and should be at least 32 random bytes; Block, if set,
+// encrypts them with AES-GCM and must be 16, 24 or 32 bytes long.
+type CookieKey struct {
+	Hash  []byte
+	Block []byte
+}
+
+type cookieKey struct {
+	hash []byte
+	aead cipher.AEAD
+}
+
+// SecureCodec signs and optionally encrypts values, with an optional
+// expiry, for storing in cookies or other untrusted places. Values are
+// encoded with the first key and decoded with any of them, so keys can be
+// rotated by prepending a new one and dropping the oldest later.
+type SecureCodec struct {
+	Clock Clock
+
+	keys []cookieKey
+}
+
+func NewSecureCodec(keys ...CookieKey) (*SecureCodec, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("webgo: at least one cookie key is required")
+	}
+	codec := &SecureCodec{Clock: SystemClock}
+	for _, k := range keys {
+		if len(k.Hash) == 0 {
+			return nil, errors.New("webgo: cookie hash key is empty")
+		}
+		ck := cookieKey{hash: k.Hash}
+		if len(k.Block) > 0 {
+			block, err := aes.NewCipher(k.Block)
+			if err != nil {
+				return nil, err
+			}
+			if ck.aead, err = cipher.NewGCM(block); err != nil {
+				return nil, err
+			}
+		}
+		codec.keys = append(codec.keys, ck)
+	}
+	return codec, nil
+}
+
+// Encode authenticates value for use under name; a value encoded for one
+// name fails to decode under another. It expires after ttl, or never if
+// ttl is zero.
+func (c *SecureCodec) Encode(name string, value []byte, ttl time.Duration) (string, error) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.Clock.Now().Add(ttl)
+	}
+	return c.encodeAt(name, value, expires)
+}
+
+// Decode verifies and decrypts a value produced by Encode. It fails with
+// ErrInvalidSignature for forged or corrupted values and values encoded
+// with retired keys, and with ErrCookieExpired once the value expired.
+func (c *SecureCodec) Decode(name, encoded string) ([]byte, error) {
+	return c.decodeAt(name, encoded, c.Clock.Now())
+}
+
+// encodeAt returns base64(body) "." base64(mac), where body is the expiry
+// (0 for none) followed by the value, sealed when the key has a block key.
+// The MAC covers name too.
+func (c *SecureCodec) encodeAt(name string, value []byte, expires time.Time) (string, error) {
+	k := c.keys[0]
+	body := make([]byte, 8, 8+len(value))
+	if !expires.IsZero() {
+		binary.BigEndian.PutUint64(body, uint64(expires.Unix()))
+	}
+	body = append(body, value...)
+
+	if k.aead != nil {
+		nonce := make([]byte, k.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		body = k.aead.Seal(nonce, nonce, body, []byte(name))
+	}
+
+	enc := base64.RawURLEncoding
+	encoded := enc.EncodeToString(body) + "." + enc.EncodeToString(codecMAC(k.hash, name, body))
+	if len(name)+1+len(encoded) > 4096 {
+		return "", ErrCookieTooLarge
+	}
+	return encoded, nil
+}
+
+func (c *SecureCodec) decodeAt(name, encoded string, now time.Time) ([]byte, error) {
+	i := strings.LastIndexByte(encoded, '.')
+	if i < 0 {
+		return nil, ErrInvalidSignature
+	}
+	enc := base64.RawURLEncoding
+	body, err := enc.DecodeString(encoded[:i])
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	mac, err := enc.DecodeString(encoded[i+1:])
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	for _, k := range c.keys {
+		if !hmac.Equal(mac, codecMAC(k.hash, name, body)) {
+			continue
+		}
+		plain := body
+		if k.aead != nil {
+			n := k.aead.NonceSize()
+			if len(body) < n {
+				return nil, ErrInvalidSignature
+			}
+			if plain, err = k.aead.Open(nil, body[:n], body[n:], []byte(name)); err != nil {
+				return nil, ErrInvalidSignature
+			}
+		}
+		if len(plain) < 8 {
+			return nil, ErrInvalidSignature
+		}
+		if expires := int64(binary.BigEndian.Uint64(plain)); expires != 0 && now.Unix() >= expires {
+			return nil, ErrCookieExpired
+		}
+		return plain[8:], nil
+	}
+	return nil, ErrInvalidSignature
+}
+
+func codecMAC(key []byte, name string, body []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{'|'})
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// SetSecureCookie sets c with its value encoded by codec. The value
+// expires with the cookie's MaxAge, if it has one.
+func (resp *Response) SetSecureCookie(c *http.Cookie, codec *SecureCodec) error {
+	var ttl time.Duration
+	if c.MaxAge > 0 {
+		ttl = time.Duration(c.MaxAge) * time.Second
+	}
+	value, err := codec.Encode(c.Name, []byte(c.Value), ttl)
+	if err != nil {
+		return err
+	}
+	secured := *c
+	secured.Value = value
+	resp.SetCookie(&secured)
+	return nil
+}
+
+// SecureCookie returns the decoded value of a cookie set with
+// SetSecureCookie.
+func (req *Request) SecureCookie(name string, codec *SecureCodec) (string, error) {
+	c, err := req.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	value, err := codec.Decode(name, c.Value)
+	return string(value), err
+}
@@ -0,0 +1,183 @@
+package webgo
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	oldCookieKey = CookieKey{Hash: bytes.Repeat([]byte("o"), 32), Block: bytes.Repeat([]byte("O"), 32)}
+	newCookieKey = CookieKey{Hash: bytes.Repeat([]byte("n"), 32), Block: bytes.Repeat([]byte("N"), 16)}
+	signOnlyKey  = CookieKey{Hash: bytes.Repeat([]byte("s"), 32)}
+)
+
+func mustCodec(t *testing.T, keys ...CookieKey) *SecureCodec {
+	t.Helper()
+	codec, err := NewSecureCodec(keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return codec
+}
+
+func TestSecureCodecDecode(t *testing.T) {
+	codec := mustCodec(t, signOnlyKey)
+	encoded, err := codec.Encode("id", []byte("alice"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, mac, _ := strings.Cut(encoded, ".")
+	flip := func(s string) string {
+		b := []byte(s)
+		if b[0] == 'A' {
+			b[0] = 'B'
+		} else {
+			b[0] = 'A'
+		}
+		return string(b)
+	}
+
+	for _, tt := range []struct {
+		name, cookie, encoded string
+		want                  string
+		err                   error
+	}{
+		{"Valid", "id", encoded, "alice", nil},
+		{"OtherName", "user", encoded, "", ErrInvalidSignature},
+		{"TamperedBody", "id", flip(body) + "." + mac, "", ErrInvalidSignature},
+		{"TamperedMAC", "id", body + "." + flip(mac), "", ErrInvalidSignature},
+		{"NoMAC", "id", body, "", ErrInvalidSignature},
+		{"NotBase64", "id", "!!!.???", "", ErrInvalidSignature},
+		{"Empty", "id", "", "", ErrInvalidSignature},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := codec.Decode(tt.cookie, tt.encoded)
+			if err != tt.err || string(got) != tt.want {
+				t.Errorf("Decode() = %q, %v; want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestSecureCodecEncrypts(t *testing.T) {
+	codec := mustCodec(t, newCookieKey)
+	encoded, err := codec.Encode("id", []byte("alice"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(encoded, "YWxpY2") { // base64 of "alice"
+		t.Errorf("encrypted value %q shows the plaintext", encoded)
+	}
+	if got, err := codec.Decode("id", encoded); err != nil || string(got) != "alice" {
+		t.Errorf("Decode() = %q, %v", got, err)
+	}
+}
+
+func TestSecureCodecKeyRotation(t *testing.T) {
+	old := mustCodec(t, oldCookieKey)
+	rotated := mustCodec(t, newCookieKey, oldCookieKey)
+	retired := mustCodec(t, newCookieKey)
+
+	fromOld, _ := old.Encode("id", []byte("alice"), 0)
+	fromRotated, _ := rotated.Encode("id", []byte("bob"), 0)
+
+	for _, tt := range []struct {
+		name    string
+		codec   *SecureCodec
+		encoded string
+		want    string
+		err     error
+	}{
+		{"OldKeyStillAccepted", rotated, fromOld, "alice", nil},
+		{"EncodedWithNewKey", retired, fromRotated, "bob", nil},
+		{"OldKeyRetired", retired, fromOld, "", ErrInvalidSignature},
+		{"NewKeyUnknown", old, fromRotated, "", ErrInvalidSignature},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.codec.Decode("id", tt.encoded)
+			if err != tt.err || string(got) != tt.want {
+				t.Errorf("Decode() = %q, %v; want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestSecureCodecExpiry(t *testing.T) {
+	clock := NewFakeClock(time.Time{})
+	codec := mustCodec(t, signOnlyKey)
+	codec.Clock = clock
+	expiring, _ := codec.Encode("id", []byte("alice"), time.Hour)
+	forever, _ := codec.Encode("id", []byte("bob"), 0)
+
+	for _, tt := range []struct {
+		name    string
+		advance time.Duration
+		encoded string
+		err     error
+	}{
+		{"Fresh", 59 * time.Minute, expiring, nil},
+		{"Expired", time.Minute, expiring, ErrCookieExpired},
+		{"NoExpiry", 24 * time.Hour, forever, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Advance(tt.advance)
+			if _, err := codec.Decode("id", tt.encoded); err != tt.err {
+				t.Errorf("Decode() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSecureCodecErrors(t *testing.T) {
+	if _, err := NewSecureCodec(); err == nil {
+		t.Error("NewSecureCodec() without keys succeeded")
+	}
+	if _, err := NewSecureCodec(CookieKey{}); err == nil {
+		t.Error("NewSecureCodec() with an empty hash key succeeded")
+	}
+	if _, err := NewSecureCodec(CookieKey{Hash: signOnlyKey.Hash, Block: []byte("short")}); err == nil {
+		t.Error("NewSecureCodec() with a bad block key succeeded")
+	}
+	codec := mustCodec(t, signOnlyKey)
+	if _, err := codec.Encode("id", make([]byte, 4096), 0); err != ErrCookieTooLarge {
+		t.Errorf("Encode() of a huge value: error = %v, want ErrCookieTooLarge", err)
+	}
+}
+
+// cookieRequest returns a request carrying the cookies resp sets.
+func cookieRequest(resp *Response) *Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	for _, c := range (&http.Response{Header: resp.Headers}).Cookies() {
+		r.AddCookie(c)
+	}
+	return ParseRequest(r)
+}
+
+func TestSignedCookieSharesSecureCookieFormat(t *testing.T) {
+	key := signOnlyKey.Hash
+	resp := NoContent().SetSignedCookie(&http.Cookie{Name: "id", Value: "alice"}, key)
+	req := cookieRequest(resp)
+
+	for _, tt := range []struct {
+		name string
+		get  func() (string, error)
+		want string
+		err  error
+	}{
+		{"SignedCookie", func() (string, error) { return req.SignedCookie("id", key) }, "alice", nil},
+		{"SecureCookie", func() (string, error) { return req.SecureCookie("id", mustCodec(t, signOnlyKey)) }, "alice", nil},
+		{"WrongKey", func() (string, error) { return req.SignedCookie("id", []byte("other")) }, "", ErrInvalidSignature},
+		{"Missing", func() (string, error) { return req.SignedCookie("none", key) }, "", ErrNoCookie},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.get()
+			if err != tt.err || got != tt.want {
+				t.Errorf("got %q, %v; want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package webgo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionStore persists session payloads. A token is whatever the store
+// needs the session cookie to carry: an ID for server-side stores, the
+// payload itself for cookie stores. Save receives the current token, ""
+// for a new session, and returns the one to send to the client.
+type SessionStore interface {
+	Load(ctx context.Context, token string) ([]byte, error)
+	Save(ctx context.Context, token string, data []byte, ttl time.Duration) (string, error)
+	Delete(ctx context.Context, token string) error
+}
+
+// Sessions is the middleware loading and saving sessions through a
+// SessionStore. Sessions are loaded lazily on the first call to
+// Request.Session and saved only when they changed.
+type Sessions struct {
+	Store      SessionStore
+	CookieName string
+	Path       string
+	Domain     string
+	Secure     bool
+	SameSite   http.SameSite
+	Lifetime   time.Duration
+
+	// BrowserSession leaves the cookie without an expiry, so browsers
+	// drop it when they close; the store still enforces Lifetime.
+	BrowserSession bool
+
+	// IdleTimeout ends sessions unused for that long, AbsoluteTimeout
+	// those created that long ago, however active. Zero disables them.
+	IdleTimeout     time.Duration
+	AbsoluteTimeout time.Duration
+
+	// PrivilegeKeys are session keys whose change, like a login, gives
+	// the session a new ID to defeat session fixation.
+	PrivilegeKeys []string
+}
+
+func NewSessions(store SessionStore) *Sessions {
+	return &Sessions{
+		Store:      store,
+		CookieName: "session",
+		Path:       "/",
+		SameSite:   http.SameSiteLaxMode,
+		Lifetime:   24 * time.Hour,
+
+		PrivilegeKeys: []string{sessionUserKey},
+	}
+}
+
+func (s *Sessions) Middleware(next ProcessFunc) ProcessFunc {
+	return func(req *Request) *Response {
+		req.sessions = s
+		resp := next(req)
+		if sess := req.session; sess != nil {
+			s.commit(req, resp, sess)
+		}
+		return resp
+	}
+}
+
+// Session is a set of values kept across the requests of one client.
+// Values are stored JSON-encoded; Get returns them as decoded into an
+// interface{}, Decode into a value of the caller's choosing.
+type Session struct {
+	mu        sync.Mutex
+	sessions  *Sessions
+	token     string
+	old       string
+	values    map[string]json.RawMessage
+	created   time.Time
+	accessed  time.Time
+	dirty     bool
+	destroyed bool
+}
+
+type sessionPayload struct {
+	Values   map[string]json.RawMessage `json:"v"`
+	Created  int64                      `json:"c"`
+	Accessed int64                      `json:"a"`
+}
+
+// Session returns the client's session, loading it on first use. Without
+// the Sessions middleware it returns an empty session that is never saved.
+func (req *Request) Session() *Session {
+	if req.session != nil {
+		return req.session
+	}
+	req.session = &Session{sessions: req.sessions, values: make(map[string]json.RawMessage)}
+	if req.sessions != nil {
+		req.sessions.load(req, req.session)
+	}
+	return req.session
+}
+
+func (s *Sessions) load(req *Request, sess *Session) {
+	c, err := req.Cookie(s.CookieName)
+	if err != nil || c.Value == "" {
+		return
+	}
+	data, err := s.Store.Load(req.Context(), c.Value)
+	if err != nil {
+		if err != ErrSessionNotFound && req.app != nil {
+			req.app.logf("webgo: loading session: %v", err)
+		}
+		return
+	}
+	var payload sessionPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return
+	}
+
+	now := req.Clock().Now()
+	created, accessed := time.Unix(payload.Created, 0), time.Unix(payload.Accessed, 0)
+	if (s.AbsoluteTimeout > 0 && now.Sub(created) >= s.AbsoluteTimeout) ||
+		(s.IdleTimeout > 0 && now.Sub(accessed) >= s.IdleTimeout) {
+		// the client gets a fresh session; the stale one must not linger
+		s.Store.Delete(req.Context(), c.Value)
+		return
+	}
+
+	sess.token = c.Value
+	sess.created, sess.accessed = created, accessed
+	if payload.Values != nil {
+		sess.values = payload.Values
+	}
+	if s.IdleTimeout > 0 && now.Sub(accessed) >= s.IdleTimeout/10 {
+		// keep an active session alive without writing it on every request
+		sess.dirty = true
+	}
+}
+
+func (s *Sessions) commit(req *Request, resp *Response, sess *Session) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if sess.old != "" {
+		if err := s.Store.Delete(req.Context(), sess.old); err != nil && req.app != nil {
+			req.app.logf("webgo: deleting session: %v", err)
+		}
+		if sess.destroyed {
+			resp.SetCookie(s.cookie("", -1))
+		}
+		sess.old = ""
+	}
+	if !sess.dirty {
+		return
+	}
+
+	now := req.Clock().Now()
+	if sess.created.IsZero() {
+		sess.created = now
+	}
+	sess.accessed = now
+	ttl := s.Lifetime
+	if s.IdleTimeout > 0 && s.IdleTimeout < ttl {
+		ttl = s.IdleTimeout
+	}
+	if s.AbsoluteTimeout > 0 {
+		if left := sess.created.Add(s.AbsoluteTimeout).Sub(now); left < ttl {
+			ttl = left
+		}
+	}
+
+	data, err := json.Marshal(sessionPayload{
+		Values:   sess.values,
+		Created:  sess.created.Unix(),
+		Accessed: sess.accessed.Unix(),
+	})
+	if err == nil {
+		sess.token, err = s.Store.Save(req.Context(), sess.token, data, ttl)
+	}
+	if err != nil {
+		if req.app != nil {
+			req.app.logf("webgo: saving session: %v", err)
+		}
+		return
+	}
+	sess.dirty = false
+	maxAge := int(s.Lifetime / time.Second)
+	if s.BrowserSession {
+		maxAge = 0
+	}
+	resp.SetCookie(s.cookie(sess.token, maxAge))
+}
+
+func (s *Sessions) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     s.CookieName,
+		Value:    value,
+		Path:     s.Path,
+		Domain:   s.Domain,
+		Secure:   s.Secure,
+		HttpOnly: true,
+		SameSite: s.SameSite,
+		MaxAge:   maxAge,
+	}
+}
+
+func (sess *Session) Get(key string) interface{} {
+	var v interface{}
+	sess.Decode(key, &v)
+	return v
+}
+
+func (sess *Session) GetString(key string) string {
+	var v string
+	sess.Decode(key, &v)
+	return v
+}
+
+func (sess *Session) GetInt(key string) int {
+	var v int
+	sess.Decode(key, &v)
+	return v
+}
+
+func (sess *Session) GetBool(key string) bool {
+	var v bool
+	sess.Decode(key, &v)
+	return v
+}
+
+// Decode unmarshals the value stored under key into v. It returns false if
+// there is no such value or it doesn't fit v.
+func (sess *Session) Decode(key string, v interface{}) bool {
+	sess.mu.Lock()
+	raw, ok := sess.values[key]
+	sess.mu.Unlock()
+	return ok && json.Unmarshal(raw, v) == nil
+}
+
+func (sess *Session) Has(key string) bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	_, ok := sess.values[key]
+	return ok
+}
+
+// Set stores v, which must be JSON-encodable, under key.
+func (sess *Session) Set(key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if old, ok := sess.values[key]; sess.privileged(key) && (!ok || string(old) != string(raw)) {
+		sess.regenerate()
+	}
+	sess.values[key] = raw
+	sess.dirty = true
+	sess.destroyed = false
+	return nil
+}
+
+func (sess *Session) Delete(key string) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if _, ok := sess.values[key]; ok {
+		if sess.privileged(key) {
+			sess.regenerate()
+		}
+		delete(sess.values, key)
+		sess.dirty = true
+	}
+}
+
+// Destroy removes the session from the store and the client. Setting a
+// value afterwards starts a new session.
+func (sess *Session) Destroy() {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.token != "" {
+		sess.old, sess.token = sess.token, ""
+	}
+	sess.values = make(map[string]json.RawMessage)
+	sess.created = time.Time{}
+	sess.destroyed = true
+	sess.dirty = false
+}
+
+// Regenerate moves the session to a new ID, keeping its values, and
+// invalidates the old one. Sessions does this by itself when a
+// PrivilegeKeys value changes.
+func (sess *Session) Regenerate() {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.regenerate()
+	sess.dirty = true
+}
+
+func (sess *Session) regenerate() {
+	if sess.token != "" {
+		sess.old, sess.token = sess.token, ""
+	}
+	// created stays: a new ID doesn't extend the AbsoluteTimeout
+}
+
+func (sess *Session) privileged(key string) bool {
+	return sess.sessions != nil && containsString(sess.sessions.PrivilegeKeys, key)
+}
+
+const sessionUserKey = "_user"
+
+// Login records the authenticated user in the session, regenerating its
+// ID.
+func (sess *Session) Login(userID string) {
+	sess.Set(sessionUserKey, userID)
+}
+
+// UserID returns the user recorded by Login, or "".
+func (sess *Session) UserID() string {
+	return sess.GetString(sessionUserKey)
+}
+
+// Logout ends the session.
+func (sess *Session) Logout() {
+	sess.Destroy()
+}
+
+func (sess *Session) id() string {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.token
+}
+
+// IsNew reports whether the session has not been saved yet.
+func (sess *Session) IsNew() bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.token == ""
+}
+
+// newSessionID returns a random, URL-safe session ID for server-side
+// stores.
+func newSessionID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
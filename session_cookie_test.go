@@ -0,0 +1,68 @@
+package webgo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCookieStoreLoad(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(time.Time{})
+	newStore := func(keys ...CookieKey) *CookieStore {
+		t.Helper()
+		cs, err := NewCookieStore(keys...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs.Clock = clock
+		return cs
+	}
+	old := newStore(oldCookieKey)
+	token, err := old.Save(ctx, "", []byte("user=alice"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(token, "alice") {
+		t.Errorf("encrypted token %q exposes the session data", token)
+	}
+
+	tampered := []byte(token)
+	if i := len(tampered) / 2; tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+
+	for _, tt := range []struct {
+		name    string
+		store   *CookieStore
+		token   string
+		advance time.Duration
+		want    string
+		err     error
+	}{
+		{"Valid", old, token, 0, "user=alice", nil},
+		{"RotatedKey", newStore(newCookieKey, oldCookieKey), token, 0, "user=alice", nil},
+		{"RetiredKey", newStore(newCookieKey), token, 0, "", ErrSessionNotFound},
+		{"Tampered", old, string(tampered), 0, "", ErrSessionNotFound},
+		{"Garbage", old, "not-a-session", 0, "", ErrSessionNotFound},
+		{"Expired", old, token, 2 * time.Hour, "", ErrSessionNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Advance(tt.advance)
+			defer clock.Advance(-tt.advance)
+			got, err := tt.store.Load(ctx, tt.token)
+			if err != tt.err || string(got) != tt.want {
+				t.Errorf("Load() = %q, %v; want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestCookieStoreNeedsKey(t *testing.T) {
+	if _, err := NewCookieStore(); err == nil {
+		t.Error("NewCookieStore() with no keys returned no error")
+	}
+}
@@ -0,0 +1,140 @@
+package webgo
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps sessions in process memory, for development and
+// single-instance deployments. Sessions are lost on restart.
+type MemoryStore struct {
+	Clock Clock
+
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+	stop       chan struct{}
+	stopOnce   sync.Once
+}
+
+type memoryEntry struct {
+	token   string
+	data    []byte
+	expires time.Time
+}
+
+// NewMemoryStore returns a store purging expired sessions every
+// gcInterval (never, if it is zero) and holding at most maxEntries
+// sessions (any number, if it is zero). When full, the least recently
+// used session is evicted to make room.
+func NewMemoryStore(gcInterval time.Duration, maxEntries int) *MemoryStore {
+	ms := &MemoryStore{
+		Clock:      SystemClock,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		stop:       make(chan struct{}),
+	}
+	if gcInterval > 0 {
+		go ms.gcLoop(gcInterval)
+	}
+	return ms
+}
+
+func (ms *MemoryStore) Load(ctx context.Context, token string) ([]byte, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	el, ok := ms.entries[token]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	e := el.Value.(*memoryEntry)
+	if !ms.Clock.Now().Before(e.expires) {
+		ms.remove(el)
+		return nil, ErrSessionNotFound
+	}
+	ms.order.MoveToFront(el)
+	return e.data, nil
+}
+
+func (ms *MemoryStore) Save(ctx context.Context, token string, data []byte, ttl time.Duration) (string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	expires := ms.Clock.Now().Add(ttl)
+	if el, ok := ms.entries[token]; ok && token != "" {
+		e := el.Value.(*memoryEntry)
+		e.data, e.expires = append([]byte(nil), data...), expires
+		ms.order.MoveToFront(el)
+		return token, nil
+	}
+
+	token = newSessionID()
+	if ms.maxEntries > 0 && len(ms.entries) >= ms.maxEntries {
+		ms.remove(ms.order.Back())
+	}
+	ms.entries[token] = ms.order.PushFront(&memoryEntry{
+		token:   token,
+		data:    append([]byte(nil), data...),
+		expires: expires,
+	})
+	return token, nil
+}
+
+func (ms *MemoryStore) Delete(ctx context.Context, token string) error {
+	ms.mu.Lock()
+	if el, ok := ms.entries[token]; ok {
+		ms.remove(el)
+	}
+	ms.mu.Unlock()
+	return nil
+}
+
+func (ms *MemoryStore) Len() int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return len(ms.entries)
+}
+
+// GC removes expired sessions now and returns how many there were.
+func (ms *MemoryStore) GC() int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	now := ms.Clock.Now()
+	n := 0
+	for el := ms.order.Front(); el != nil; {
+		next := el.Next()
+		if !now.Before(el.Value.(*memoryEntry).expires) {
+			ms.remove(el)
+			n++
+		}
+		el = next
+	}
+	return n
+}
+
+// Close stops the periodic collection.
+func (ms *MemoryStore) Close() error {
+	ms.stopOnce.Do(func() { close(ms.stop) })
+	return nil
+}
+
+func (ms *MemoryStore) gcLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ms.GC()
+		case <-ms.stop:
+			return
+		}
+	}
+}
+
+func (ms *MemoryStore) remove(el *list.Element) {
+	ms.order.Remove(el)
+	delete(ms.entries, el.Value.(*memoryEntry).token)
+}
@@ -0,0 +1,59 @@
+package webgo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	ms := NewMemoryStore(0, 2)
+	a, _ := ms.Save(ctx, "", []byte("a"), time.Hour)
+	b, _ := ms.Save(ctx, "", []byte("b"), time.Hour)
+	if _, err := ms.Load(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	c, _ := ms.Save(ctx, "", []byte("c"), time.Hour)
+
+	for _, tt := range []struct {
+		name, token string
+		err         error
+	}{
+		{"Used", a, nil},
+		{"Evicted", b, ErrSessionNotFound},
+		{"New", c, nil},
+	} {
+		if _, err := ms.Load(ctx, tt.token); err != tt.err {
+			t.Errorf("%s: Load() error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+	if n := ms.Len(); n != 2 {
+		t.Errorf("Len() = %d, want 2", n)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(time.Time{})
+	ms := NewMemoryStore(0, 0)
+	ms.Clock = clock
+	short, _ := ms.Save(ctx, "", []byte("short"), time.Minute)
+	long, _ := ms.Save(ctx, "", []byte("long"), time.Hour)
+
+	// saving under an unknown token never adopts it
+	if token, _ := ms.Save(ctx, "chosen-by-client", nil, time.Hour); token == "chosen-by-client" {
+		t.Error("Save kept a token the store didn't issue")
+	}
+
+	clock.Advance(2 * time.Minute)
+	if n := ms.GC(); n != 1 {
+		t.Errorf("GC() = %d, want 1", n)
+	}
+	if _, err := ms.Load(ctx, short); err != ErrSessionNotFound {
+		t.Errorf("expired session: Load() error = %v", err)
+	}
+	if data, err := ms.Load(ctx, long); err != nil || string(data) != "long" {
+		t.Errorf("live session: Load() = %q, %v", data, err)
+	}
+}
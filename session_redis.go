@@ -0,0 +1,207 @@
+package webgo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// RedisClient runs a Redis command. Replies are returned as string
+// (status), []byte (bulk), int64, []interface{} or nil; error replies as
+// RedisError. NewRedisClient provides a small built-in implementation;
+// other clients can be adapted to it.
+type RedisClient interface {
+	Do(ctx context.Context, args ...string) (interface{}, error)
+}
+
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// RedisStore keeps sessions in Redis under Prefix+ID, letting Redis expire
+// them.
+type RedisStore struct {
+	Client RedisClient
+	Prefix string
+}
+
+func NewRedisStore(client RedisClient) *RedisStore {
+	return &RedisStore{Client: client, Prefix: "session:"}
+}
+
+func (rs *RedisStore) Load(ctx context.Context, token string) ([]byte, error) {
+	reply, err := rs.Client.Do(ctx, "GET", rs.Prefix+token)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return data, nil
+}
+
+func (rs *RedisStore) Save(ctx context.Context, token string, data []byte, ttl time.Duration) (string, error) {
+	if token == "" {
+		token = newSessionID()
+	}
+	ms := ttl.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	_, err := rs.Client.Do(ctx, "SET", rs.Prefix+token, string(data), "PX", strconv.FormatInt(ms, 10))
+	return token, err
+}
+
+func (rs *RedisStore) Delete(ctx context.Context, token string) error {
+	_, err := rs.Client.Do(ctx, "DEL", rs.Prefix+token)
+	return err
+}
+
+// redisTimeout bounds commands whose context has no deadline, so a stalled
+// server can't hang requests forever.
+const redisTimeout = 5 * time.Second
+
+// NewRedisClient returns a RedisClient speaking RESP over TCP to addr,
+// keeping up to maxIdle connections open. password and db are sent with
+// AUTH and SELECT when non-empty and non-zero.
+func NewRedisClient(addr, password string, db, maxIdle int) RedisClient {
+	return &redisPool{
+		addr:     addr,
+		password: password,
+		db:       db,
+		idle:     make(chan *redisConn, maxIdle),
+	}
+}
+
+type redisPool struct {
+	addr     string
+	password string
+	db       int
+	idle     chan *redisConn
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (p *redisPool) Do(ctx context.Context, args ...string) (interface{}, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, redisTimeout)
+		defer cancel()
+	}
+	c, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := c.do(ctx, args...)
+	if _, ok := err.(RedisError); err != nil && !ok {
+		c.Close()
+		return nil, err
+	}
+	select {
+	case p.idle <- c:
+	default:
+		c.Close()
+	}
+	return reply, err
+}
+
+func (p *redisPool) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case c := <-p.idle:
+		return c, nil
+	default:
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{Conn: conn, r: bufio.NewReader(conn)}
+	if p.password != "" {
+		if _, err := c.do(ctx, "AUTH", p.password); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if p.db != 0 {
+		if _, err := c.do(ctx, "SELECT", strconv.Itoa(p.db)); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *redisConn) do(ctx context.Context, args ...string) (interface{}, error) {
+	deadline, _ := ctx.Deadline()
+	c.SetDeadline(deadline)
+
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := c.Write(buf); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("redis: malformed reply")
+	}
+	kind, line := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return line, nil
+	case '-':
+		return nil, RedisError(line)
+	case ':':
+		return strconv.ParseInt(line, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				if _, ok := err.(RedisError); !ok {
+					return nil, err
+				}
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply type %q", kind)
+}
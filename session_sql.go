@@ -0,0 +1,103 @@
+package webgo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLStore keeps sessions in a database/sql table with token, data and
+// expires_at (Unix seconds) columns; CreateTable creates it. Expired rows
+// are ignored and removed when read, and DeleteExpired purges the rest.
+type SQLStore struct {
+	DB    *sql.DB
+	Table string
+	Clock Clock
+
+	// Numbered selects $1-style placeholders, for PostgreSQL, instead of ?.
+	Numbered bool
+
+	// MySQL saves with ON DUPLICATE KEY UPDATE instead of the ON CONFLICT
+	// clause PostgreSQL and SQLite understand.
+	MySQL bool
+}
+
+func NewSQLStore(db *sql.DB, table string) *SQLStore {
+	return &SQLStore{DB: db, Table: table, Clock: SystemClock}
+}
+
+func (ss *SQLStore) CreateTable(ctx context.Context) error {
+	_, err := ss.DB.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+ss.Table+
+		" (token VARCHAR(64) PRIMARY KEY, data TEXT NOT NULL, expires_at BIGINT NOT NULL)")
+	return err
+}
+
+// query fills in the table name and rewrites ? placeholders when
+// Numbered is set.
+func (ss *SQLStore) query(q string) string {
+	q = fmt.Sprintf(q, ss.Table)
+	if !ss.Numbered {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (ss *SQLStore) Load(ctx context.Context, token string) ([]byte, error) {
+	var data string
+	var expires int64
+	err := ss.DB.QueryRowContext(ctx, ss.query("SELECT data, expires_at FROM %s WHERE token = ?"), token).Scan(&data, &expires)
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if ss.Clock.Now().Unix() >= expires {
+		ss.Delete(ctx, token)
+		return nil, ErrSessionNotFound
+	}
+	return []byte(data), nil
+}
+
+func (ss *SQLStore) Save(ctx context.Context, token string, data []byte, ttl time.Duration) (string, error) {
+	if token == "" {
+		token = newSessionID()
+	}
+	// a single upsert, so concurrent saves of one session can't both
+	// find no row and collide on the INSERT
+	q := "INSERT INTO %s (token, data, expires_at) VALUES (?, ?, ?) " +
+		"ON CONFLICT (token) DO UPDATE SET data = excluded.data, expires_at = excluded.expires_at"
+	if ss.MySQL {
+		q = "INSERT INTO %s (token, data, expires_at) VALUES (?, ?, ?) " +
+			"ON DUPLICATE KEY UPDATE data = VALUES(data), expires_at = VALUES(expires_at)"
+	}
+	_, err := ss.DB.ExecContext(ctx, ss.query(q), token, string(data), ss.Clock.Now().Add(ttl).Unix())
+	return token, err
+}
+
+func (ss *SQLStore) Delete(ctx context.Context, token string) error {
+	_, err := ss.DB.ExecContext(ctx, ss.query("DELETE FROM %s WHERE token = ?"), token)
+	return err
+}
+
+// DeleteExpired removes every expired session and returns how many there
+// were. Call it periodically to keep the table small.
+func (ss *SQLStore) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := ss.DB.ExecContext(ctx, ss.query("DELETE FROM %s WHERE expires_at <= ?"), ss.Clock.Now().Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
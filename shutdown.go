@@ -0,0 +1,65 @@
+package webgo
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Shutdown stops accepting connections and waits for in-flight requests to
+// finish or ctx to expire. Run returns once Shutdown has completed.
+func (app *Application) Shutdown(ctx context.Context) error {
+	SdNotify("STOPPING=1")
+	app.Health().SetDraining(true)
+	if app.shutdownDelay > 0 {
+		select {
+		case <-app.Clock().After(app.shutdownDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	err := app.httpServer.Shutdown(ctx)
+	app.mu.Lock()
+	shutdownFuncs := append([]func(context.Context) error(nil), app.shutdownFuncs...)
+	app.mu.Unlock()
+	for _, shutdown := range shutdownFuncs {
+		if e := shutdown(ctx); err == nil {
+			err = e
+		}
+	}
+	app.shutdownOnce.Do(func() {
+		if e := app.runStopHooks(ctx); err == nil {
+			err = e
+		}
+		close(app.shutdownDone)
+	})
+	return err
+}
+
+// SetShutdownDelay keeps serving for d after Shutdown is called, with
+// readiness reporting "draining", so load balancers stop routing new
+// traffic before the listeners close.
+func (app *Application) SetShutdownDelay(d time.Duration) {
+	app.shutdownDelay = d
+}
+
+// ShutdownOnSignal shuts the application down gracefully when one of sigs
+// (SIGINT and SIGTERM by default) arrives, giving in-flight requests up to
+// drainTimeout to complete.
+func (app *Application) ShutdownOnSignal(drainTimeout time.Duration, sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		<-ch
+		signal.Stop(ch)
+		ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		defer cancel()
+		app.Shutdown(ctx)
+	}()
+}
@@ -0,0 +1,64 @@
+package webgo
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SlowRequest struct {
+	Method    string
+	Path      string
+	Route     string
+	Arguments []string
+	Status    int
+	Duration  time.Duration
+	Timings   []ServerTiming
+}
+
+func (s *SlowRequest) String() string {
+	var b strings.Builder
+	b.WriteString("slow request: " + s.Method + " " + s.Path)
+	if s.Route != "" {
+		b.WriteString(" (route " + s.Route + ")")
+	}
+	if len(s.Arguments) > 0 {
+		b.WriteString(" args=[" + strings.Join(s.Arguments, ", ") + "]")
+	}
+	b.WriteString(" status=" + strconv.Itoa(s.Status) + " took " + s.Duration.String())
+	if len(s.Timings) > 0 {
+		b.WriteString(": " + serverTimingHeader(s.Timings))
+	}
+	return b.String()
+}
+
+// LogSlowRequests logs every request taking longer than threshold, with
+// its route, arguments and Server-Timing spans, and passes it to the
+// optional alert callbacks.
+func (app *Application) LogSlowRequests(threshold time.Duration, alerts ...func(*SlowRequest)) {
+	app.Use(func(next ProcessFunc) ProcessFunc {
+		return func(req *Request) *Response {
+			start := req.Clock().Now()
+			resp := next(req)
+			elapsed := req.Clock().Since(start)
+			if elapsed < threshold {
+				return resp
+			}
+
+			slow := &SlowRequest{
+				Method:    req.Method,
+				Path:      req.Path,
+				Route:     req.Route(),
+				Arguments: req.Arguments,
+				Status:    resp.Status,
+				Duration:  elapsed,
+				Timings:   append(append([]ServerTiming(nil), req.timings...), resp.timings...),
+			}
+			app.logf("webgo: %s", slow)
+			for _, alert := range alerts {
+				alert(slow)
+			}
+			return resp
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package webgo
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// SetBodySpill makes request bodies larger than threshold bytes go to a
+// temporary file in dir, or the system's if dir is empty, instead of
+// memory, so multi-gigabyte uploads are taken within a bounded budget.
+// Handlers read such bodies through BodyReader; Body is nil. JSON-RPC,
+// GraphQL, contracts and the Recorder read them back in full. The file is
+// removed once the response has been written. Zero, the default, keeps
+// every body in memory.
+func (app *Application) SetBodySpill(threshold int64, dir string) {
+	app.spillThreshold = threshold
+	app.spillDir = dir
+}
+
+// readSpilling reads the body into Body if it is at most threshold bytes,
+// and into a temporary file otherwise.
+func (req *Request) readSpilling(body io.Reader, threshold int64, dir string) error {
+	var head []byte
+	if req.raw.ContentLength <= threshold {
+		data, err := ioutil.ReadAll(io.LimitReader(body, threshold+1))
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) <= threshold {
+			req.Body = data
+			return nil
+		}
+		head = data
+	}
+
+	f, err := ioutil.TempFile(dir, "webgo-body-")
+	if err != nil {
+		return err
+	}
+	req.bodyFile = f
+	if _, err := f.Write(head); err != nil {
+		return err
+	}
+	n, err := copyBuffered(f, body)
+	if err != nil {
+		return err
+	}
+	req.bodySize = int64(len(head)) + n
+	return nil
+}
+
+func (req *Request) removeBodyFile() {
+	if req.bodyFile == nil {
+		return
+	}
+	req.bodyFile.Close()
+	if err := os.Remove(req.bodyFile.Name()); err != nil && req.app != nil {
+		req.app.logf("webgo: removing spilled request body: %v", err)
+	}
+}
+
+// bodyBytes returns the whole buffered body, reading it back from disk if
+// it was spilled, for the parts of the framework that need it in memory.
+func (req *Request) bodyBytes() ([]byte, error) {
+	if req.bodyFile == nil {
+		return req.Body, nil
+	}
+	return ioutil.ReadAll(req.BodyReader())
+}
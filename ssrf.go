@@ -0,0 +1,161 @@
+package webgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned, wrapped, for outbound connections an
+// SSRFGuard refuses.
+var ErrBlockedAddress = errors.New("connection to blocked address")
+
+// blockedNets are ranges not covered by the net.IP predicates that still
+// must not be reachable from user-supplied URLs.
+var blockedNets = parseCIDRs(
+	"0.0.0.0/8",      // "this" network
+	"100.64.0.0/10",  // carrier-grade NAT
+	"192.0.0.0/24",   // IETF protocol assignments
+	"198.18.0.0/15",  // benchmarking
+	"240.0.0.0/4",    // reserved, including broadcast
+	"64:ff9b::/96",   // NAT64, which can embed any IPv4 address
+	"64:ff9b:1::/48", // local-use NAT64
+	"2002::/16",      // 6to4, likewise
+	"100::/64",       // discard-only
+)
+
+// SSRFGuard makes outbound requests refuse to connect to loopback,
+// private, link-local (including cloud metadata endpoints), multicast and
+// reserved addresses. The check runs on the address actually dialed,
+// after DNS resolution and on every redirect, so hostnames resolving or
+// rebinding to internal addresses are caught too.
+type SSRFGuard struct {
+	// AllowNets are ranges reachable despite being blocked otherwise.
+	AllowNets []*net.IPNet
+
+	// AllowHosts are host names whose addresses are never checked.
+	AllowHosts []string
+
+	// Ports, if set, are the only ports that may be dialed.
+	Ports []int
+
+	once      sync.Once
+	transport *http.Transport
+}
+
+// NewSSRFGuard returns a guard with allow added to its allowlist. Each
+// entry is a CIDR range, a single IP or a host name.
+func NewSSRFGuard(allow ...string) (*SSRFGuard, error) {
+	g := &SSRFGuard{}
+	for _, a := range allow {
+		if _, n, err := net.ParseCIDR(a); err == nil {
+			g.AllowNets = append(g.AllowNets, n)
+		} else if ip := net.ParseIP(a); ip != nil {
+			g.AllowNets = append(g.AllowNets, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+		} else if a != "" && !strings.ContainsAny(a, "/ ") {
+			g.AllowHosts = append(g.AllowHosts, strings.ToLower(a))
+		} else {
+			return nil, fmt.Errorf("webgo: invalid SSRF allowlist entry %q", a)
+		}
+	}
+	return g, nil
+}
+
+// Check returns an error if ip may not be connected to.
+func (g *SSRFGuard) Check(ip net.IP) error {
+	for _, n := range g.AllowNets {
+		if n.Contains(ip) {
+			return nil
+		}
+	}
+	if blockedIP(ip) {
+		return fmt.Errorf("%w %s", ErrBlockedAddress, ip)
+	}
+	return nil
+}
+
+func blockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// DialContext dials like net.Dialer, refusing blocked addresses.
+func (g *SSRFGuard) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	trusted := containsString(g.AllowHosts, strings.ToLower(strings.TrimSuffix(host, ".")))
+	d := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			if trusted {
+				return nil
+			}
+			return g.checkAddr(address)
+		},
+	}
+	return d.DialContext(ctx, network, addr)
+}
+
+func (g *SSRFGuard) checkAddr(address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w %s", ErrBlockedAddress, host)
+	}
+	if len(g.Ports) > 0 {
+		allowed := false
+		for _, p := range g.Ports {
+			allowed = allowed || fmt.Sprint(p) == port
+		}
+		if !allowed {
+			return fmt.Errorf("%w %s (port not allowed)", ErrBlockedAddress, address)
+		}
+	}
+	return g.Check(ip)
+}
+
+// Transport returns a transport dialing through the guard. It never uses
+// an HTTP proxy, since the guard would only get to check the proxy's
+// address.
+func (g *SSRFGuard) Transport() *http.Transport {
+	g.once.Do(func() {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.Proxy = nil
+		t.DialContext = g.DialContext
+		g.transport = t
+	})
+	return g.transport
+}
+
+// Client returns an http.Client using Transport.
+func (g *SSRFGuard) Client() *http.Client {
+	return &http.Client{Transport: g.Transport()}
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, c := range cidrs {
+		_, nets[i], _ = net.ParseCIDR(c)
+	}
+	return nets
+}
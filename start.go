@@ -0,0 +1,60 @@
+package webgo
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// Start binds addr and serves in the background, returning as soon as the
+// listener is ready. Use Addr to find the port picked for ":0".
+func (app *Application) Start(addr string) error {
+	if err := app.runStartHooks(); err != nil {
+		return err
+	}
+	if addr == "" {
+		addr = ":http"
+	}
+	l, err := app.listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	app.setAddr(l.Addr())
+	app.trackListener(l)
+	app.notifyReady()
+
+	app.serveErr = make(chan error, 1)
+	go func() {
+		if err := app.httpServer.Serve(app.wrapListener(l)); err != http.ErrServerClosed {
+			app.serveErr <- err
+		}
+		close(app.serveErr)
+	}()
+	return nil
+}
+
+// Stop gracefully shuts down an application started with Start, reporting
+// a serve error if the background server had already failed.
+func (app *Application) Stop(ctx context.Context) error {
+	err := app.Shutdown(ctx)
+	if app.serveErr != nil {
+		if serveErr := <-app.serveErr; serveErr != nil {
+			return serveErr
+		}
+	}
+	return err
+}
+
+// Addr returns the address of the most recently bound listener, or nil
+// before the application listens.
+func (app *Application) Addr() net.Addr {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	return app.addr
+}
+
+func (app *Application) setAddr(addr net.Addr) {
+	app.mu.Lock()
+	app.addr = addr
+	app.mu.Unlock()
+}
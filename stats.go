@@ -0,0 +1,129 @@
+package webgo
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const latencySamples = 1024
+
+type RouteStats struct {
+	Method   string        `json:"method"`
+	Route    string        `json:"route"`
+	Requests uint64        `json:"requests"`
+	Errors   uint64        `json:"errors"`
+	Bytes    uint64        `json:"bytes"`
+	P50      time.Duration `json:"p50_ns"`
+	P95      time.Duration `json:"p95_ns"`
+	P99      time.Duration `json:"p99_ns"`
+}
+
+// routeCounters keeps totals plus a ring of the most recent latencies, from
+// which percentiles are estimated.
+type routeCounters struct {
+	requests uint64
+	errors   uint64
+	bytes    uint64
+	samples  []time.Duration
+	next     int
+}
+
+func (c *routeCounters) add(latency time.Duration) {
+	if len(c.samples) < latencySamples {
+		c.samples = append(c.samples, latency)
+		return
+	}
+	c.samples[c.next] = latency
+	c.next = (c.next + 1) % latencySamples
+}
+
+type statsCollector struct {
+	mu     sync.Mutex
+	routes map[routeKey]*routeCounters
+}
+
+func (sc *statsCollector) middleware(next ProcessFunc) ProcessFunc {
+	return func(req *Request) *Response {
+		start := req.Clock().Now()
+		resp := next(req)
+		elapsed := req.Clock().Since(start)
+		key := metricLabels(req)
+
+		sc.mu.Lock()
+		defer sc.mu.Unlock()
+		c := sc.routes[key]
+		if c == nil {
+			c = &routeCounters{}
+			sc.routes[key] = c
+		}
+		c.requests++
+		if resp.Status >= 500 {
+			c.errors++
+		}
+		if size := responseSize(resp); size > 0 {
+			c.bytes += uint64(size)
+		}
+		c.add(elapsed)
+		return resp
+	}
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p)
+	return sorted[i]
+}
+
+func (sc *statsCollector) snapshot() []RouteStats {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	stats := make([]RouteStats, 0, len(sc.routes))
+	for key, c := range sc.routes {
+		sorted := append([]time.Duration(nil), c.samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		stats = append(stats, RouteStats{
+			Method:   key.method,
+			Route:    key.route,
+			Requests: c.requests,
+			Errors:   c.errors,
+			Bytes:    c.bytes,
+			P50:      percentile(sorted, .50),
+			P95:      percentile(sorted, .95),
+			P99:      percentile(sorted, .99),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Route != stats[j].Route {
+			return stats[i].Route < stats[j].Route
+		}
+		return stats[i].Method < stats[j].Method
+	})
+	return stats
+}
+
+// EnableStats starts tracking per-route counters and latency percentiles.
+// When path is not empty they are also served there as JSON.
+func (app *Application) EnableStats(path string) {
+	if app.stats == nil {
+		app.stats = &statsCollector{routes: make(map[routeKey]*routeCounters)}
+		app.Use(app.stats.middleware)
+	}
+	if path != "" {
+		app.Route("GET "+path, func(req *Request) *Response {
+			return JSON(200, app.Stats())
+		})
+	}
+}
+
+// Stats returns a snapshot of the per-route statistics, or nil unless
+// EnableStats was called.
+func (app *Application) Stats() []RouteStats {
+	if app.stats == nil {
+		return nil
+	}
+	return app.stats.snapshot()
+}
@@ -0,0 +1,91 @@
+package webgo
+
+import (
+	"net/http"
+)
+
+func Text(status int, msg string) *Response {
+	resp := Respond(status, []byte(msg+"\n"))
+	resp.Headers["Content-Type"] = contentTypeText
+	resp.Headers["X-Content-Type-Options"] = noSniff
+	return resp
+}
+
+func statusText(status int, msg string) *Response {
+	if msg == "" {
+		msg = http.StatusText(status)
+	}
+	return Text(status, msg)
+}
+
+func errorText(status int, err error) *Response {
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	resp := statusText(status, msg)
+	resp.err = err
+	return resp
+}
+
+func NoContent() *Response {
+	return Respond(http.StatusNoContent, nil)
+}
+
+func NotModified() *Response {
+	return Respond(http.StatusNotModified, nil)
+}
+
+func BadRequest(err error) *Response {
+	return errorText(http.StatusBadRequest, err)
+}
+
+func Unauthorized(msg string) *Response {
+	return statusText(http.StatusUnauthorized, msg)
+}
+
+func Forbidden(msg string) *Response {
+	return statusText(http.StatusForbidden, msg)
+}
+
+func NotFound(msg string) *Response {
+	return statusText(http.StatusNotFound, msg)
+}
+
+func MethodNotAllowed(allowed ...string) *Response {
+	resp := statusText(http.StatusMethodNotAllowed, "")
+	for _, method := range allowed {
+		resp.Headers.Add("Allow", method)
+	}
+	return resp
+}
+
+func Conflict(msg string) *Response {
+	return statusText(http.StatusConflict, msg)
+}
+
+func UnprocessableEntity(err error) *Response {
+	return errorText(http.StatusUnprocessableEntity, err)
+}
+
+func TooManyRequests(msg string) *Response {
+	return statusText(http.StatusTooManyRequests, msg)
+}
+
+// InternalError never exposes err to the client; it is kept on the
+// Response for logging only.
+func InternalError(err error) *Response {
+	resp := statusText(http.StatusInternalServerError, "")
+	resp.err = err
+	return resp
+}
+
+func ServiceUnavailable(msg string) *Response {
+	return statusText(http.StatusServiceUnavailable, msg)
+}
+
+func HTML(status int, body string) *Response {
+	resp := Respond(status, []byte(body))
+	resp.Headers["Content-Type"] = contentTypeHTML
+	return resp
+}
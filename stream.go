@@ -0,0 +1,155 @@
+package webgo
+
+import (
+	"net/http"
+	"strings"
+)
+
+type StreamWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newStreamWriter(w http.ResponseWriter) *StreamWriter {
+	flusher, _ := w.(http.Flusher)
+	return &StreamWriter{
+		w:       w,
+		flusher: flusher,
+	}
+}
+
+func (sw *StreamWriter) Write(p []byte) (int, error) {
+	return sw.w.Write(p)
+}
+
+func (sw *StreamWriter) WriteString(s string) (int, error) {
+	return sw.w.Write([]byte(s))
+}
+
+// Flush sends any buffered data to the client right away.
+func (sw *StreamWriter) Flush() {
+	if sw.flusher != nil {
+		sw.flusher.Flush()
+	}
+}
+
+// SetTrailer sets a trailer to be sent after the body. Trailers should be
+// announced up front with Response.DeclareTrailers; undeclared ones are
+// still sent, but clients may not expect them.
+func (sw *StreamWriter) SetTrailer(name, value string) {
+	name = http.CanonicalHeaderKey(name)
+	for _, declared := range sw.w.Header().Values("Trailer") {
+		for _, n := range strings.Split(declared, ",") {
+			if http.CanonicalHeaderKey(strings.TrimSpace(n)) == name {
+				sw.w.Header().Set(name, value)
+				return
+			}
+		}
+	}
+	sw.w.Header().Set(http.TrailerPrefix+name, value)
+}
+
+func (resp *Response) DeclareTrailers(names ...string) *Response {
+	for _, name := range names {
+		resp.AddHeader("Trailer", http.CanonicalHeaderKey(name))
+	}
+	return resp
+}
+
+// Stream returns a Response whose body is produced by fn after the status
+// and headers have been written, instead of being buffered in Body.
+func Stream(status int, fn func(*StreamWriter) error) *Response {
+	resp := Respond(status, nil)
+	resp.StreamFunc = fn
+	return resp
+}
+
+// WriterFunc writes a response itself: it sets the status and headers on
+// w, then writes the body.
+type WriterFunc func(req *Request, w *ResponseWriter) error
+
+// Writer adapts fn into a ProcessFunc. What fn writes goes to the client
+// as it is produced instead of being built up in Body first, so large
+// generated responses such as CSV exports or zip archives are served in
+// constant memory. Headers set on the returned Response, for instance by
+// middleware, are sent along. If fn fails before writing anything, its
+// error is answered as usual; once the status is out it can only be
+// logged.
+func Writer(fn WriterFunc) ProcessFunc {
+	return func(req *Request) *Response {
+		resp := Respond(200, nil)
+		resp.serveHTTP = func(w http.ResponseWriter) {
+			header := w.Header().Clone()
+			rw := &ResponseWriter{sw: newStreamWriter(w)}
+			err := fn(req, rw)
+			if err == nil {
+				rw.WriteHeader(200)
+				return
+			}
+			if rw.status != 0 {
+				if req.app != nil {
+					req.app.logf("webgo: writing response to %s %s: %v", req.Method, req.Path, err)
+				}
+				return
+			}
+			// drop whatever fn set for the response it didn't write
+			for name := range w.Header() {
+				delete(w.Header(), name)
+			}
+			for name, values := range header {
+				w.Header()[name] = values
+			}
+			if req.app == nil {
+				w.WriteHeader(500)
+				return
+			}
+			errResp := req.app.HandleError(req, err)
+			req.app.reportError(req, errResp)
+			req.app.writeResponse(w, req.raw, errResp)
+			if req.app.poolResponses {
+				releaseResponse(errResp)
+			}
+		}
+		return resp
+	}
+}
+
+// ResponseWriter is what a WriterFunc writes its response to. The status
+// is sent by WriteHeader, or as 200 by the first write or flush; headers
+// must be set before that.
+type ResponseWriter struct {
+	sw     *StreamWriter
+	status int
+}
+
+func (rw *ResponseWriter) Header() http.Header {
+	return rw.sw.w.Header()
+}
+
+func (rw *ResponseWriter) WriteHeader(status int) {
+	if rw.status != 0 {
+		return
+	}
+	rw.status = status
+	rw.sw.w.WriteHeader(status)
+}
+
+func (rw *ResponseWriter) Write(p []byte) (int, error) {
+	rw.WriteHeader(200)
+	return rw.sw.Write(p)
+}
+
+func (rw *ResponseWriter) WriteString(s string) (int, error) {
+	rw.WriteHeader(200)
+	return rw.sw.WriteString(s)
+}
+
+func (rw *ResponseWriter) Flush() {
+	rw.WriteHeader(200)
+	rw.sw.Flush()
+}
+
+// SetTrailer sets a trailer, as StreamWriter.SetTrailer does.
+func (rw *ResponseWriter) SetTrailer(name, value string) {
+	rw.sw.SetTrailer(name, value)
+}
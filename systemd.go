@@ -0,0 +1,77 @@
+package webgo
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// SdNotify sends state (e.g. "READY=1") to systemd. It does nothing when
+// the process wasn't started by a Type=notify unit.
+func SdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+func watchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// notifyReady tells systemd the application is serving, and keeps its
+// watchdog fed for as long as the health checks pass. Draining doesn't
+// stop it: the process is still alive, and systemd must not kill it
+// while it finishes its requests.
+func (app *Application) notifyReady() {
+	app.readyOnce.Do(func() {
+		if os.Getenv("NOTIFY_SOCKET") == "" {
+			return
+		}
+		if err := SdNotify("READY=1"); err != nil {
+			app.logf("webgo: sd_notify: %v", err)
+			return
+		}
+
+		interval := watchdogInterval()
+		if interval == 0 {
+			return
+		}
+		go func() {
+			ticker := time.NewTicker(interval / 2)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-app.shutdownDone:
+					return
+				case <-ticker.C:
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), interval/2)
+				alive := app.Health().Run(ctx).checksPass()
+				cancel()
+				if alive {
+					SdNotify("WATCHDOG=1")
+				}
+			}
+		}()
+	})
+}
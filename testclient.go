@@ -0,0 +1,123 @@
+package webgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+type TestOption func(*http.Request)
+
+func TestHeader(name, value string) TestOption {
+	return func(r *http.Request) {
+		r.Header.Add(name, value)
+	}
+}
+
+func TestCookie(c *http.Cookie) TestOption {
+	return func(r *http.Request) {
+		r.AddCookie(c)
+	}
+}
+
+func TestContext(ctx context.Context) TestOption {
+	return func(r *http.Request) {
+		*r = *r.WithContext(ctx)
+	}
+}
+
+// TestRequest runs a request through the full middleware and routing
+// pipeline in-process, without a listener, and returns the response as
+// the client would see it.
+func (app *Application) TestRequest(method, path string, body []byte, opts ...TestOption) *Response {
+	r, err := http.NewRequest(method, path, bytes.NewReader(body))
+	if err != nil {
+		return BadRequest(err)
+	}
+	r.RequestURI = r.URL.RequestURI()
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Host = "example.com"
+	for _, opt := range opts {
+		opt(r)
+	}
+	return app.serveBuffered(r)
+}
+
+// TestRequestBuilder builds a test request step by step:
+//
+//	resp := app.Test("POST", "/users").JSON(user).Header("X-Token", "t").Do()
+type TestRequestBuilder struct {
+	app    *Application
+	method string
+	path   string
+	query  url.Values
+	body   []byte
+	opts   []TestOption
+	err    error
+}
+
+func (app *Application) Test(method, path string) *TestRequestBuilder {
+	return &TestRequestBuilder{
+		app:    app,
+		method: method,
+		path:   path,
+		query:  make(url.Values),
+	}
+}
+
+func (b *TestRequestBuilder) Header(name, value string) *TestRequestBuilder {
+	b.opts = append(b.opts, TestHeader(name, value))
+	return b
+}
+
+func (b *TestRequestBuilder) Cookie(c *http.Cookie) *TestRequestBuilder {
+	b.opts = append(b.opts, TestCookie(c))
+	return b
+}
+
+func (b *TestRequestBuilder) Query(name, value string) *TestRequestBuilder {
+	b.query.Add(name, value)
+	return b
+}
+
+func (b *TestRequestBuilder) Context(ctx context.Context) *TestRequestBuilder {
+	b.opts = append(b.opts, TestContext(ctx))
+	return b
+}
+
+func (b *TestRequestBuilder) Body(body []byte) *TestRequestBuilder {
+	b.body = body
+	return b
+}
+
+func (b *TestRequestBuilder) JSON(v interface{}) *TestRequestBuilder {
+	b.body, b.err = json.Marshal(v)
+	return b.Header("Content-Type", "application/json")
+}
+
+func (b *TestRequestBuilder) Form(values url.Values) *TestRequestBuilder {
+	b.body = []byte(values.Encode())
+	return b.Header("Content-Type", "application/x-www-form-urlencoded")
+}
+
+func (b *TestRequestBuilder) Do() *Response {
+	if b.err != nil {
+		return BadRequest(b.err)
+	}
+	path := b.path
+	if len(b.query) > 0 {
+		u, err := url.Parse(path)
+		if err != nil {
+			return BadRequest(err)
+		}
+		q := u.Query()
+		for name, values := range b.query {
+			q[name] = append(q[name], values...)
+		}
+		u.RawQuery = q.Encode()
+		path = u.String()
+	}
+	return b.app.TestRequest(b.method, path, b.body, b.opts...)
+}
@@ -0,0 +1,67 @@
+package webgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+)
+
+// TestServer runs an application on a loopback listener for tests that
+// need a real HTTP client.
+type TestServer struct {
+	*httptest.Server
+}
+
+func NewTestServer(app *Application) *TestServer {
+	return &TestServer{httptest.NewServer(app)}
+}
+
+func NewTLSTestServer(app *Application) *TestServer {
+	return &TestServer{httptest.NewTLSServer(app)}
+}
+
+func (ts *TestServer) Do(method, path string, body []byte, headers http.Header) (*Response, error) {
+	r, err := http.NewRequest(method, ts.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range headers {
+		r.Header[name] = values
+	}
+	resp, err := ts.Client().Do(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseResponse(resp), nil
+}
+
+func (ts *TestServer) Get(path string) (*Response, error) {
+	return ts.Do("GET", path, nil, nil)
+}
+
+func (ts *TestServer) PostJSON(path string, v interface{}) (*Response, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return ts.Do("POST", path, body, http.Header{"Content-Type": {"application/json"}})
+}
+
+// NewTestRequest builds a Request for calling a ProcessFunc directly. The
+// query string in path is parsed into Query; route arguments can be set
+// with WithArguments.
+func NewTestRequest(method, path string, body []byte) *Request {
+	r := httptest.NewRequest(method, path, bytes.NewReader(body))
+	return ParseRequest(r)
+}
+
+func (req *Request) WithArguments(args ...string) *Request {
+	req.Arguments = args
+	return req
+}
+
+func (req *Request) WithHeader(name, value string) *Request {
+	req.Headers.Add(name, value)
+	return req
+}
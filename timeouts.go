@@ -0,0 +1,31 @@
+package webgo
+
+import (
+	"time"
+)
+
+type Timeouts struct {
+	Read       time.Duration
+	ReadHeader time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+}
+
+// DefaultTimeouts bounds how long clients may take to send headers and how
+// long idle keep-alive connections are kept. Read and write timeouts are
+// left unset since they would cut off slow uploads and streamed responses.
+var DefaultTimeouts = Timeouts{
+	ReadHeader: 10 * time.Second,
+	Idle:       2 * time.Minute,
+}
+
+func (app *Application) SetTimeouts(t Timeouts) {
+	app.httpServer.ReadTimeout = t.Read
+	app.httpServer.ReadHeaderTimeout = t.ReadHeader
+	app.httpServer.WriteTimeout = t.Write
+	app.httpServer.IdleTimeout = t.Idle
+}
+
+func (app *Application) SetMaxHeaderBytes(n int) {
+	app.httpServer.MaxHeaderBytes = n
+}
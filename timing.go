@@ -0,0 +1,62 @@
+package webgo
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ServerTiming struct {
+	Name        string
+	Duration    time.Duration
+	Description string
+}
+
+func (t ServerTiming) String() string {
+	var b strings.Builder
+	b.WriteString(t.Name)
+	if t.Duration > 0 {
+		b.WriteString(";dur=")
+		b.WriteString(strconv.FormatFloat(float64(t.Duration)/float64(time.Millisecond), 'f', -1, 64))
+	}
+	if t.Description != "" {
+		b.WriteString(";desc=")
+		b.WriteString(strconv.Quote(t.Description))
+	}
+	return b.String()
+}
+
+func (resp *Response) Timing(name string, d time.Duration) *Response {
+	resp.timings = append(resp.timings, ServerTiming{Name: name, Duration: d})
+	return resp
+}
+
+func (resp *Response) TimingDesc(name string, d time.Duration, desc string) *Response {
+	resp.timings = append(resp.timings, ServerTiming{Name: name, Duration: d, Description: desc})
+	return resp
+}
+
+func (req *Request) Timing(name string, d time.Duration) {
+	req.timings = append(req.timings, ServerTiming{Name: name, Duration: d})
+}
+
+// StartTiming starts a span and returns the func that ends it, e.g.
+// defer req.StartTiming("db")().
+func (req *Request) StartTiming(name string) func() {
+	start := req.Clock().Now()
+	return func() {
+		req.Timing(name, req.Clock().Since(start))
+	}
+}
+
+func (req *Request) Timings() []ServerTiming {
+	return req.timings
+}
+
+func serverTimingHeader(timings []ServerTiming) string {
+	parts := make([]string, len(timings))
+	for i, t := range timings {
+		parts[i] = t.String()
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,24 @@
+package webgo
+
+import (
+	"crypto/tls"
+)
+
+// TLSConfig sets the TLS configuration used by RunTLS, e.g. to raise the
+// minimum version, restrict cipher suites or require client certificates.
+func (app *Application) TLSConfig(config *tls.Config) {
+	app.httpServer.TLSConfig = config
+}
+
+// RunTLS serves HTTPS on addr. certFile and keyFile may be empty if the
+// TLSConfig already provides certificates.
+func (app *Application) RunTLS(addr, certFile, keyFile string) error {
+	if addr == "" {
+		addr = ":https"
+	}
+	l, err := app.listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return app.ServeTLS(l, certFile, keyFile)
+}
@@ -0,0 +1,174 @@
+package webgo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidTraceParent = errors.New("invalid traceparent header")
+
+// TraceContext holds the W3C trace context of a request: the traceparent
+// fields, the vendor tracestate and the baggage members.
+type TraceContext struct {
+	TraceID  [16]byte
+	ParentID [8]byte
+	Flags    byte
+	State    string
+	Baggage  map[string]string
+}
+
+func (tc *TraceContext) Sampled() bool {
+	return tc.Flags&1 == 1
+}
+
+func (tc *TraceContext) TraceIDString() string {
+	return hex.EncodeToString(tc.TraceID[:])
+}
+
+// TraceParent formats the traceparent header value.
+func (tc *TraceContext) TraceParent() string {
+	return "00-" + hex.EncodeToString(tc.TraceID[:]) + "-" +
+		hex.EncodeToString(tc.ParentID[:]) + "-" + hex.EncodeToString([]byte{tc.Flags})
+}
+
+func ParseTraceParent(value string) (*TraceContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return nil, ErrInvalidTraceParent
+	}
+	// version 00 has exactly four fields; later versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return nil, ErrInvalidTraceParent
+	}
+
+	tc := &TraceContext{}
+	var flags [1]byte
+	if _, err := hex.Decode(tc.TraceID[:], []byte(parts[1])); err != nil {
+		return nil, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(tc.ParentID[:], []byte(parts[2])); err != nil {
+		return nil, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return nil, ErrInvalidTraceParent
+	}
+	if tc.TraceID == [16]byte{} || tc.ParentID == [8]byte{} {
+		return nil, ErrInvalidTraceParent
+	}
+	tc.Flags = flags[0]
+	return tc, nil
+}
+
+func ParseBaggage(value string) map[string]string {
+	baggage := make(map[string]string)
+	for _, member := range strings.Split(value, ",") {
+		member, _, _ = strings.Cut(member, ";")
+		key, val, ok := strings.Cut(member, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		if v, err := url.PathUnescape(strings.TrimSpace(val)); err == nil {
+			baggage[key] = v
+		}
+	}
+	return baggage
+}
+
+func FormatBaggage(baggage map[string]string) string {
+	members := make([]string, 0, len(baggage))
+	for key, val := range baggage {
+		members = append(members, key+"="+url.PathEscape(val))
+	}
+	return strings.Join(members, ",")
+}
+
+// NewTraceContext starts a new, sampled trace.
+func NewTraceContext() *TraceContext {
+	tc := &TraceContext{Flags: 1}
+	rand.Read(tc.TraceID[:])
+	rand.Read(tc.ParentID[:])
+	return tc
+}
+
+// Child returns the context to propagate on an outbound call: same trace,
+// state and baggage with a fresh parent span ID.
+func (tc *TraceContext) Child() *TraceContext {
+	child := *tc
+	rand.Read(child.ParentID[:])
+	return &child
+}
+
+// Inject sets the traceparent, tracestate and baggage headers on h.
+func (tc *TraceContext) Inject(h http.Header) {
+	h.Set("Traceparent", tc.TraceParent())
+	if tc.State != "" {
+		h.Set("Tracestate", tc.State)
+	} else {
+		h.Del("Tracestate")
+	}
+	if len(tc.Baggage) > 0 {
+		h.Set("Baggage", FormatBaggage(tc.Baggage))
+	}
+}
+
+// ExtractTraceContext reads the trace context from request headers. It
+// returns nil if there is no valid traceparent.
+func ExtractTraceContext(h http.Header) *TraceContext {
+	tc, err := ParseTraceParent(h.Get("Traceparent"))
+	if err != nil {
+		return nil
+	}
+	tc.State = strings.Join(h.Values("Tracestate"), ",")
+	if baggage := h.Values("Baggage"); len(baggage) > 0 {
+		tc.Baggage = ParseBaggage(strings.Join(baggage, ","))
+	}
+	return tc
+}
+
+type traceContextKey struct{}
+
+func ContextWithTrace(ctx context.Context, tc *TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+func TraceFromContext(ctx context.Context) *TraceContext {
+	tc, _ := ctx.Value(traceContextKey{}).(*TraceContext)
+	return tc
+}
+
+// TraceContext returns the trace context the client sent, or nil.
+func (req *Request) TraceContext() *TraceContext {
+	if tc := TraceFromContext(req.Context()); tc != nil {
+		return tc
+	}
+	return ExtractTraceContext(req.Headers)
+}
+
+// PropagateTrace is middleware that continues the caller's trace, or starts
+// a new one, and stores it in the request context so outbound calls made
+// with that context can forward it via InjectTrace.
+func PropagateTrace(next ProcessFunc) ProcessFunc {
+	return func(req *Request) *Response {
+		tc := ExtractTraceContext(req.Headers)
+		if tc == nil {
+			tc = NewTraceContext()
+		}
+		req.SetContext(ContextWithTrace(req.Context(), tc))
+		return next(req)
+	}
+}
+
+// InjectTrace forwards the trace context carried by ctx on an outbound
+// request, as a child of the current span.
+func InjectTrace(ctx context.Context, h http.Header) {
+	if tc := TraceFromContext(ctx); tc != nil {
+		tc.Child().Inject(h)
+	}
+}
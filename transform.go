@@ -0,0 +1,31 @@
+package webgo
+
+// ResponseHook may modify resp or return a replacement; returning nil keeps
+// resp as it is.
+type ResponseHook func(req *Request, resp *Response) *Response
+
+// Transform registers a hook that runs on every response right before it is
+// written, including 404s and error responses, in registration order.
+// Hooks run after all middleware, so a body may already be compressed:
+// one rewriting bodies must leave those with a Content-Encoding alone.
+func (app *Application) Transform(hook ResponseHook) {
+	app.transforms = append(app.transforms, hook)
+}
+
+func (app *Application) transform(req *Request, resp *Response) *Response {
+	for _, hook := range app.transforms {
+		if replaced := hook(req, resp); replaced != nil {
+			resp = replaced
+		}
+	}
+	return resp
+}
+
+// Route returns the pattern of the route that matched the request, or ""
+// if none did.
+func (req *Request) Route() string {
+	if req.processor == nil {
+		return ""
+	}
+	return req.processor.Pattern
+}
@@ -0,0 +1,408 @@
+package webgo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const tusVersion = "1.0.0"
+
+var ErrUploadNotFound = errors.New("upload not found")
+
+// tusIDRe matches the upload IDs create hands out. Other IDs in upload
+// URLs, like "..", never reach a store.
+var tusIDRe = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// TusUpload describes a resumable upload. Size is -1 while the client
+// defers announcing the length.
+type TusUpload struct {
+	ID       string            `json:"id"`
+	Size     int64             `json:"size"`
+	Offset   int64             `json:"offset"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Created  time.Time         `json:"created"`
+}
+
+// Complete reports whether all the upload's bytes have arrived.
+func (u *TusUpload) Complete() bool {
+	return u.Size >= 0 && u.Offset == u.Size
+}
+
+// TusStore keeps uploads and their data. Write appends what it reads from
+// r at offset, which is the upload's current offset, and returns how many
+// bytes it stored even when it fails part way, so the client can resume
+// from there.
+type TusStore interface {
+	Create(ctx context.Context, u *TusUpload) error
+	Get(ctx context.Context, id string) (*TusUpload, error)
+	SetSize(ctx context.Context, id string, size int64) error
+	Write(ctx context.Context, id string, offset int64, r io.Reader) (int64, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// Tus serves the tus 1.0 resumable upload protocol with the creation,
+// creation-defer-length and termination extensions. Clients POST to the
+// endpoint to create an upload, then PATCH its URL with chunks and HEAD
+// it to learn where to resume after an interruption.
+type Tus struct {
+	Store TusStore
+
+	// MaxSize limits the size of an upload; zero means no limit.
+	MaxSize int64
+
+	// OnComplete, if set, runs when the last chunk of an upload arrives,
+	// before the reply to that PATCH is sent.
+	OnComplete func(req *Request, u *TusUpload) error
+
+	mu      sync.Mutex
+	writing map[string]bool
+}
+
+func NewTus(store TusStore) *Tus {
+	return &Tus{Store: store}
+}
+
+// ServeTus routes the tus endpoint at path and the uploads below it to t.
+// Request bodies on these routes are streamed to the store.
+func (app *Application) ServeTus(path string, t *Tus) *Processor {
+	path = strings.TrimRight(path, "/")
+	return app.Route("(?:OPTIONS|POST|HEAD|PATCH|DELETE) "+regexp.QuoteMeta(path)+"(?:/([^/]+))?", t.Process,
+		WithStreamBody(), WithRouteDoc(RouteDoc{Hidden: true}))
+}
+
+func (t *Tus) Process(req *Request) *Response {
+	id := ""
+	if len(req.Arguments) > 0 {
+		id = req.Arguments[0]
+	}
+
+	var resp *Response
+	switch {
+	case req.Method == "OPTIONS":
+		resp = t.options()
+	case req.Headers.Get("Tus-Resumable") != tusVersion:
+		resp = Respond(412, nil)
+		resp.Headers.Set("Tus-Version", tusVersion)
+	case req.Method == "POST" && id == "":
+		resp = t.create(req)
+	case id == "":
+		resp = MethodNotAllowed("OPTIONS", "POST")
+	case !tusIDRe.MatchString(id):
+		resp = NotFound("")
+	case req.Method == "HEAD":
+		resp = t.head(req, id)
+	case req.Method == "PATCH":
+		resp = t.patch(req, id)
+	case req.Method == "DELETE":
+		resp = t.delete(req, id)
+	default:
+		resp = MethodNotAllowed("HEAD", "PATCH", "DELETE")
+	}
+	resp.Headers.Set("Tus-Resumable", tusVersion)
+	return resp
+}
+
+func (t *Tus) options() *Response {
+	resp := NoContent()
+	resp.Headers.Set("Tus-Version", tusVersion)
+	resp.Headers.Set("Tus-Extension", "creation,creation-defer-length,termination")
+	if t.MaxSize > 0 {
+		resp.Headers.Set("Tus-Max-Size", strconv.FormatInt(t.MaxSize, 10))
+	}
+	return resp
+}
+
+func (t *Tus) create(req *Request) *Response {
+	u := &TusUpload{ID: randomHex(16), Size: -1, Created: req.Clock().Now()}
+	if req.Headers.Get("Upload-Defer-Length") != "1" {
+		size, err := strconv.ParseInt(req.Headers.Get("Upload-Length"), 10, 64)
+		if err != nil || size < 0 {
+			return Text(400, "missing or invalid Upload-Length")
+		}
+		u.Size = size
+	}
+	if t.MaxSize > 0 && u.Size > t.MaxSize {
+		return Text(413, "upload too large")
+	}
+	meta, err := parseTusMetadata(req.Headers.Get("Upload-Metadata"))
+	if err != nil {
+		return Text(400, "invalid Upload-Metadata")
+	}
+	u.Metadata = meta
+
+	if err := t.Store.Create(req.Context(), u); err != nil {
+		return InternalError(err)
+	}
+	resp := Respond(201, nil)
+	resp.Headers.Set("Location", strings.TrimRight(req.BasePath()+req.Path, "/")+"/"+u.ID)
+	return resp
+}
+
+func (t *Tus) head(req *Request, id string) *Response {
+	u, err := t.Store.Get(req.Context(), id)
+	if err != nil {
+		return t.storeError(err)
+	}
+	resp := Respond(200, nil)
+	resp.Headers.Set("Cache-Control", "no-store")
+	resp.Headers.Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	if u.Size < 0 {
+		resp.Headers.Set("Upload-Defer-Length", "1")
+	} else {
+		resp.Headers.Set("Upload-Length", strconv.FormatInt(u.Size, 10))
+	}
+	if len(u.Metadata) > 0 {
+		resp.Headers.Set("Upload-Metadata", formatTusMetadata(u.Metadata))
+	}
+	return resp
+}
+
+func (t *Tus) patch(req *Request, id string) *Response {
+	if req.Headers.Get("Content-Type") != "application/offset+octet-stream" {
+		return Text(415, "Content-Type must be application/offset+octet-stream")
+	}
+	offset, err := strconv.ParseInt(req.Headers.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return Text(400, "missing or invalid Upload-Offset")
+	}
+
+	// one writer per upload; a client retrying while its previous PATCH
+	// still runs is told to wait
+	t.mu.Lock()
+	if t.writing == nil {
+		// for a Tus built as a struct literal
+		t.writing = make(map[string]bool)
+	}
+	busy := t.writing[id]
+	t.writing[id] = true
+	t.mu.Unlock()
+	if busy {
+		return Text(423, "upload is being written")
+	}
+	defer func() {
+		t.mu.Lock()
+		delete(t.writing, id)
+		t.mu.Unlock()
+	}()
+
+	u, err := t.Store.Get(req.Context(), id)
+	if err != nil {
+		return t.storeError(err)
+	}
+	if offset != u.Offset {
+		return Text(409, "Upload-Offset does not match the upload")
+	}
+	if u.Size < 0 {
+		if v := req.Headers.Get("Upload-Length"); v != "" {
+			size, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || size < u.Offset || (t.MaxSize > 0 && size > t.MaxSize) {
+				return Text(400, "invalid Upload-Length")
+			}
+			if err := t.Store.SetSize(req.Context(), id, size); err != nil {
+				return InternalError(err)
+			}
+			u.Size = size
+		}
+	}
+
+	body := req.BodyReader()
+	switch {
+	case u.Size >= 0:
+		body = io.LimitReader(body, u.Size-u.Offset)
+	case t.MaxSize > 0:
+		body = io.LimitReader(body, t.MaxSize-u.Offset)
+	}
+	n, err := t.Store.Write(req.Context(), id, u.Offset, body)
+	u.Offset += n
+	if err != nil {
+		if req.app != nil {
+			req.app.logf("webgo: writing upload %s at %d: %v", id, u.Offset, err)
+		}
+		if n == 0 {
+			return InternalError(err)
+		}
+	}
+
+	if u.Complete() && t.OnComplete != nil {
+		if err := t.OnComplete(req, u); err != nil {
+			if req.app != nil {
+				return req.app.HandleError(req, err)
+			}
+			return InternalError(err)
+		}
+	}
+	resp := NoContent()
+	resp.Headers.Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	return resp
+}
+
+func (t *Tus) delete(req *Request, id string) *Response {
+	if err := t.Store.Delete(req.Context(), id); err != nil {
+		return t.storeError(err)
+	}
+	return NoContent()
+}
+
+func (t *Tus) storeError(err error) *Response {
+	if errors.Is(err, ErrUploadNotFound) {
+		return NotFound("")
+	}
+	return InternalError(err)
+}
+
+// parseTusMetadata decodes "key base64value,key2 base64value2".
+func parseTusMetadata(header string) (map[string]string, error) {
+	if strings.TrimSpace(header) == "" {
+		return nil, nil
+	}
+	meta := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("empty metadata key")
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, err
+		}
+		meta[key] = string(decoded)
+	}
+	return meta, nil
+}
+
+func formatTusMetadata(meta map[string]string) string {
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + " " + base64.StdEncoding.EncodeToString([]byte(meta[key]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// FileTusStore keeps uploads in a directory: the data in a file named
+// after the upload's ID and its description next to it in ID.info.
+type FileTusStore struct {
+	Dir string
+}
+
+func NewFileTusStore(dir string) (*FileTusStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileTusStore{Dir: dir}, nil
+}
+
+// Path returns the file holding an upload's data, for handing a finished
+// upload on.
+func (s *FileTusStore) Path(id string) string {
+	return filepath.Join(s.Dir, filepath.Base(id))
+}
+
+func (s *FileTusStore) Create(ctx context.Context, u *TusUpload) error {
+	if !tusIDRe.MatchString(u.ID) {
+		return fmt.Errorf("invalid upload ID %q", u.ID)
+	}
+	f, err := os.OpenFile(s.Path(u.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return s.saveInfo(u)
+}
+
+func (s *FileTusStore) Get(ctx context.Context, id string) (*TusUpload, error) {
+	if !tusIDRe.MatchString(id) {
+		return nil, ErrUploadNotFound
+	}
+	data, err := ioutil.ReadFile(s.Path(id) + ".info")
+	if os.IsNotExist(err) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var u TusUpload
+	if err := json.Unmarshal(data, &u); err != nil {
+		return nil, err
+	}
+	// the data file is the authority on the offset: the info file may
+	// not have been updated after a crash mid-write
+	if fi, err := os.Stat(s.Path(id)); err == nil {
+		u.Offset = fi.Size()
+	}
+	return &u, nil
+}
+
+func (s *FileTusStore) SetSize(ctx context.Context, id string, size int64) error {
+	u, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	u.Size = size
+	return s.saveInfo(u)
+}
+
+func (s *FileTusStore) Write(ctx context.Context, id string, offset int64, r io.Reader) (int64, error) {
+	if !tusIDRe.MatchString(id) {
+		return 0, ErrUploadNotFound
+	}
+	f, err := os.OpenFile(s.Path(id), os.O_WRONLY, 0)
+	if os.IsNotExist(err) {
+		return 0, ErrUploadNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, r)
+	if err == nil {
+		err = f.Sync()
+	}
+	return n, err
+}
+
+func (s *FileTusStore) Delete(ctx context.Context, id string) error {
+	if !tusIDRe.MatchString(id) {
+		return ErrUploadNotFound
+	}
+	err := os.Remove(s.Path(id) + ".info")
+	if os.IsNotExist(err) {
+		return ErrUploadNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(s.Path(id))
+}
+
+func (s *FileTusStore) saveInfo(u *TusUpload) error {
+	data, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	tmp := s.Path(u.ID) + ".info.tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path(u.ID)+".info")
+}
@@ -0,0 +1,105 @@
+package webgo
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func tusRequest(app *Application, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Tus-Resumable", tusVersion)
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	return w
+}
+
+func TestTusUpload(t *testing.T) {
+	store, err := NewFileTusStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := NewApplication()
+	// built as a literal, without NewTus
+	app.ServeTus("/files", &Tus{Store: store, MaxSize: 100})
+
+	w := tusRequest(app, "POST", "/files", "", map[string]string{"Upload-Length": "11"})
+	if w.Code != 201 {
+		t.Fatalf("create: status %d", w.Code)
+	}
+	upload := w.Header().Get("Location")
+	id := upload[strings.LastIndexByte(upload, '/')+1:]
+	chunk := map[string]string{"Content-Type": "application/offset+octet-stream"}
+	at := func(offset string) map[string]string {
+		h := map[string]string{"Upload-Offset": offset}
+		for k, v := range chunk {
+			h[k] = v
+		}
+		return h
+	}
+
+	for _, tt := range []struct {
+		name, method, path, body string
+		headers                  map[string]string
+		status                   int
+		offset                   string
+	}{
+		{"FirstChunk", "PATCH", upload, "hello", at("0"), 204, "5"},
+		{"Offset", "HEAD", upload, "", nil, 200, "5"},
+		{"WrongOffset", "PATCH", upload, "world", at("3"), 409, ""},
+		{"WrongType", "PATCH", upload, "world", map[string]string{"Upload-Offset": "5"}, 415, ""},
+		{"LastChunk", "PATCH", upload, " world and more", at("5"), 204, "11"},
+		{"TooLarge", "POST", "/files", "", map[string]string{"Upload-Length": "101"}, 413, ""},
+		{"NoLength", "POST", "/files", "", nil, 400, ""},
+		{"MalformedID", "HEAD", "/files/..%2f..%2fetc", "", nil, 404, ""},
+		{"UnknownID", "HEAD", "/files/" + strings.Repeat("0", 32), "", nil, 404, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := tusRequest(app, tt.method, tt.path, tt.body, tt.headers)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Upload-Offset"); tt.offset != "" && got != tt.offset {
+				t.Errorf("Upload-Offset = %q, want %q", got, tt.offset)
+			}
+		})
+	}
+
+	data, err := ioutil.ReadFile(store.Path(id))
+	if err != nil || string(data) != "hello world" {
+		t.Errorf("stored %q, %v; want %q", data, err, "hello world")
+	}
+	if w := tusRequest(app, "DELETE", upload, "", nil); w.Code != 204 {
+		t.Errorf("delete: status %d", w.Code)
+	}
+	if w := tusRequest(app, "HEAD", upload, "", nil); w.Code != 404 {
+		t.Errorf("head after delete: status %d", w.Code)
+	}
+}
+
+func TestFileTusStoreRejectsMalformedIDs(t *testing.T) {
+	store, err := NewFileTusStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, id := range []string{"", "../outside", strings.Repeat("g", 32), strings.Repeat("0", 31)} {
+		if err := store.Create(ctx, &TusUpload{ID: id}); err == nil {
+			t.Errorf("Create(%q) succeeded", id)
+		}
+		if _, err := store.Get(ctx, id); err != ErrUploadNotFound {
+			t.Errorf("Get(%q) error = %v", id, err)
+		}
+		if _, err := store.Write(ctx, id, 0, strings.NewReader("x")); err != ErrUploadNotFound {
+			t.Errorf("Write(%q) error = %v", id, err)
+		}
+		if err := store.Delete(ctx, id); err != ErrUploadNotFound {
+			t.Errorf("Delete(%q) error = %v", id, err)
+		}
+	}
+}
@@ -1,11 +1,18 @@
 package webgo
 
 import (
+	"bytes"
+	"context"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
 	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Request struct {
@@ -15,6 +22,23 @@ type Request struct {
 	Headers   http.Header
 	Body      []byte
 	Arguments []string
+
+	timings   []ServerTiming
+	ctx       context.Context
+	raw       *http.Request
+	writer    http.ResponseWriter
+	processor *Processor
+	app       *Application
+	sessions  *Sessions
+	session   *Session
+	auth      *Auth
+	user      User
+
+	userLoaded bool
+	streamed   bool
+	basePath   string
+	bodyFile   *os.File
+	bodySize   int64
 }
 
 type Response struct {
@@ -22,25 +46,121 @@ type Response struct {
 	Headers    http.Header
 	Body       []byte
 	BodyReader io.Reader
+	StreamFunc func(*StreamWriter) error
+
+	err     error
+	name    string
+	modTime time.Time
+	timings []ServerTiming
+	stack   []byte
+
+	serveHTTP func(http.ResponseWriter)
+	headers   http.Header
+	buf       *bytes.Buffer
+	pooled    bool
 }
 
 type Processor struct {
+	Pattern string
+	Name    string
 	Match   func(path string) (bool, []string)
 	Process func(*Request) *Response
+
+	expr       string
+	reOnce     sync.Once
+	re         *regexp.Regexp
+	split      bool
+	methods    []string
+	methodRe   *regexp.Regexp
+	pathRe     *regexp.Regexp
+	segments   []routeSegment
+	doc        *RouteDoc
+	handler    ProcessFunc
+	middleware []Middleware
+	streamBody bool
 }
 
 type Application struct {
-	httpServer       *http.Server
-	processors       []*Processor
-	defaultProcessor *Processor
+	httpServer   *http.Server
+	routes       atomic.Pointer[routeTable]
+	routesMu     sync.Mutex
+	errorHandler ErrorHandler
+	logger       Logger
+	recovery     bool
+	inFlight     atomic.Int64
+	draining     atomic.Bool
+	readyOnce    sync.Once
+	drainExempt  map[string]bool
+	transforms   []ResponseHook
+	rewrites     []RewriteFunc
+	middleware   []Middleware
+	stats        *statsCollector
+	auditSinks   []AuditSink
+	auditActor   func(*Request) string
+	panicHooks   []PanicHook
+	errorHooks   []ErrorHook
+	devMode      bool
+	clock        Clock
+	certCache    interface{} // a CertCache, with the autotls build tag
+	altSvc       string
+	hsts         string
+
+	poolRequests   bool
+	poolResponses  bool
+	spillThreshold int64
+	spillDir       string
+
+	regexps sync.Map
+
+	shutdownFuncs []func(context.Context) error
+	serveErr      chan error
+
+	startHooks []LifecycleHook
+	stopHooks  []LifecycleHook
+	startOnce  sync.Once
+	startErr   error
+
+	mu            sync.Mutex
+	addr          net.Addr
+	health        *Health
+	shutdownDelay time.Duration
+	listeners     []net.Listener
+	inherited     []net.Listener
+	inheritOnce   sync.Once
+	proxyProtocol bool
+	proxyTrusted  []*net.IPNet
+
+	maxConns        int
+	connReadTimeout time.Duration
+
+	shutdownOnce sync.Once
+	shutdownDone chan struct{}
 }
 
+// Respond returns a Response with status and body. With response pooling
+// enabled, Responses are reused after being written, so make a new one
+// for every request rather than returning a shared one.
 func Respond(status int, body []byte) *Response {
-	return &Response{
-		Status:  status,
-		Body:    body,
-		Headers: make(http.Header),
+	resp := acquireResponse()
+	resp.Status = status
+	resp.Body = body
+	return resp
+}
+
+func (resp *Response) SetHeader(name, value string) *Response {
+	if resp.Headers == nil {
+		resp.Headers = make(http.Header)
+	}
+	resp.Headers.Set(name, value)
+	return resp
+}
+
+func (resp *Response) AddHeader(name, value string) *Response {
+	if resp.Headers == nil {
+		resp.Headers = make(http.Header)
 	}
+	resp.Headers.Add(name, value)
+	return resp
 }
 
 func Redirect(redirectUrl string) *Response {
@@ -49,73 +169,226 @@ func Redirect(redirectUrl string) *Response {
 	return resp
 }
 
-func NewApplication() *Application {
-	server := &http.Server{}
+func NewApplication(opts ...Option) *Application {
+	server := &http.Server{ConnState: trackConnState}
 	app := &Application{
-		httpServer: server,
+		httpServer:   server,
+		shutdownDone: make(chan struct{}),
+		recovery:     true,
 	}
 	server.Handler = app
+	app.SetTimeouts(DefaultTimeouts)
+	for _, opt := range opts {
+		opt(app)
+	}
 	return app
 }
 
-func (app *Application) Run(addr string) {
-	app.httpServer.Addr = addr
-	app.httpServer.ListenAndServe()
+// Run serves on addr until the application is shut down. It returns nil
+// after a graceful Shutdown and the listen error otherwise.
+func (app *Application) Run(addr string) error {
+	if addr == "" {
+		addr = ":http"
+	}
+	l, err := app.listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return app.Serve(l)
 }
 
-func (app *Application) SetDefaultProcessor(p *Processor) {
-	app.defaultProcessor = p
+func (app *Application) serveResult(err error) error {
+	if err == http.ErrServerClosed {
+		<-app.shutdownDone
+		return nil
+	}
+	return err
 }
 
-func (app *Application) AddProcessor(p *Processor) {
-	app.processors = append(app.processors, p)
+// match reports whether p handles the request and with which arguments,
+// which are appended to args. Routes match method and path separately;
+// processors built by hand only have Match, which gets "METHOD path".
+func (p *Processor) match(method, path string, args []string) (bool, []string) {
+	if !p.split {
+		return p.Match(method + " " + path)
+	}
+	if p.methodRe != nil && (p.methods == nil || p.methodRe.NumSubexp() > 0) {
+		m := p.methodRe.FindStringSubmatch(method)
+		if m == nil {
+			return false, nil
+		}
+		args = append(args, m[1:]...)
+	}
+	if p.segments != nil {
+		return matchSegments(p.segments, path, args)
+	}
+	m := p.pathRe.FindStringSubmatch(path)
+	if m == nil {
+		return false, nil
+	}
+	return true, append(args, m[1:]...)
 }
 
 type ProcessFunc func(*Request) *Response
 
-func (app *Application) Route(pattern string, procFunc ProcessFunc) {
+func notFound(req *Request) *Response {
+	return NotFound("")
+}
+
+// Route registers procFunc for pattern, configured by opts. The route can
+// serve requests as soon as Route returns, so set it up with opts rather
+// than the chained methods when the application is already running.
+func (app *Application) Route(pattern string, procFunc ProcessFunc, opts ...RouteOption) *Processor {
+	original := pattern
 	pattern = strings.TrimRight(pattern, "/")
 	if strings.IndexByte(pattern, ' ') < 0 {
 		pattern = ".* " + pattern
 	}
-	pattern = "^" + pattern + "$"
-	re, _ := regexp.Compile(pattern)
+	method, path, _ := strings.Cut(pattern, " ")
 
-	matchFunc := func(path string) (bool, []string) {
-		if re.MatchString(path) {
+	p := &Processor{
+		Pattern: original,
+		Process: procFunc,
+		expr:    "^" + pattern + "$",
+		handler: procFunc,
+	}
+	p.Match = func(path string) (bool, []string) {
+		if re := app.routeRegexp(p); re != nil && re.MatchString(path) {
 			return true, re.FindStringSubmatch(path)[1:]
 		}
 		return false, []string{}
 	}
 
-	app.AddProcessor(&Processor{
-		Match:   matchFunc,
-		Process: procFunc,
-	})
+	// paths the segment matcher takes need no regexp at all
+	// routes without a method, like "/users", take any, unchecked
+	var methodRe *regexp.Regexp
+	var err error
+	if method != ".*" {
+		methodRe, err = app.compileRegexp("^(?:" + method + ")$")
+	}
+	segments := routeSegments(path)
+	var pathRe *regexp.Regexp
+	if err == nil && segments == nil {
+		pathRe, err = app.compileRegexp("^(?:" + path + ")$")
+	}
+	if err == nil {
+		p.split = true
+		p.methods = routeMethods(method)
+		p.methodRe, p.pathRe, p.segments = methodRe, pathRe, segments
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	app.AddProcessor(p)
+	return p
+}
+
+// routeMethods returns the methods a route's method pattern names, like
+// "GET" or "(GET|POST)", or nil if it is a wider regular expression.
+func routeMethods(pattern string) []string {
+	if strings.HasPrefix(pattern, "(") && strings.HasSuffix(pattern, ")") {
+		pattern = strings.TrimPrefix(pattern[1:len(pattern)-1], "?:")
+	}
+	var methods []string
+	for _, m := range strings.Split(pattern, "|") {
+		if m == "" || strings.IndexFunc(m, func(c rune) bool { return c < 'A' || c > 'Z' }) >= 0 {
+			return nil
+		}
+		methods = append(methods, m)
+	}
+	return methods
 }
 
 func ParseRequest(r *http.Request) *Request {
-	method := r.Method
-	path := r.URL.Path
-	body, _ := ioutil.ReadAll(r.Body)
+	req, _ := parseRequest(r)
+	return req
+}
+
+func parseRequest(r *http.Request) (*Request, error) {
+	req := newRequest(r)
+	if err := req.readBody(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
 
-	query := make(map[string]string)
+func newRequest(r *http.Request) *Request {
+	req := acquireRequest()
+	req.Query = make(map[string]string)
 	for name, values := range r.URL.Query() {
-		query[name] = values[0]
+		req.Query[name] = values[0]
+	}
+	req.Method = r.Method
+	req.Path = r.URL.Path
+	req.Headers = r.Header
+	req.raw = r
+	return req
+}
+
+func (req *Request) readBody() error {
+	r := req.raw
+	if r.Body == nil || r.Body == http.NoBody {
+		// net/http uses NoBody for requests announcing no content, like
+		// most GETs: nothing to read, and no buffer to allocate
+		return nil
+	}
+	if req.app != nil && req.app.spillThreshold > 0 {
+		return req.readSpilling(r.Body, req.app.spillThreshold, req.app.spillDir)
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
 	}
+	req.Body = body
+	return nil
+}
+
+// BodyReader returns the request body as a stream. On routes marked with
+// StreamBody it reads from the connection, and Body is nil; elsewhere it
+// reads the buffered Body, or the file a large body was spilled to, and
+// is an io.ReadSeeker starting at the beginning of the body.
+func (req *Request) BodyReader() io.Reader {
+	if req.streamed {
+		return req.raw.Body
+	}
+	if req.bodyFile != nil {
+		return io.NewSectionReader(req.bodyFile, 0, req.bodySize)
+	}
+	return bytes.NewReader(req.Body)
+}
+
+// bodyReplayable reports whether BodyReader starts over on every call:
+// a streamed body can only be read once, unless there is none.
+func (req *Request) bodyReplayable() bool {
+	return !req.streamed || req.raw.Body == nil || req.raw.Body == http.NoBody
+}
+
+// StreamBody leaves the request body unread for this route, so large
+// uploads can be consumed through BodyReader as they arrive instead of
+// being buffered in memory first. Use WithStreamBody for routes added
+// while serving.
+func (p *Processor) StreamBody() *Processor {
+	p.streamBody = true
+	return p
+}
 
-	return &Request{
-		Method:  method,
-		Path:    path,
-		Query:   query,
-		Headers: r.Header,
-		Body:    body,
+// RemoteAddr returns the client's address, as reported by the PROXY
+// protocol header when that is enabled.
+func (req *Request) RemoteAddr() string {
+	if req.raw == nil {
+		return ""
 	}
+	return req.raw.RemoteAddr
 }
 
 func ParseResponse(resp *http.Response) *Response {
+	r, _ := parseResponse(resp)
+	return r
+}
+
+func parseResponse(resp *http.Response) (*Response, error) {
 	status := resp.StatusCode
-	body, _ := ioutil.ReadAll(resp.Body)
+	body, err := ioutil.ReadAll(resp.Body)
 	defer resp.Body.Close()
 
 	return &Response{
@@ -123,41 +396,121 @@ func ParseResponse(resp *http.Response) *Response {
 		Headers:    resp.Header,
 		Body:       body,
 		BodyReader: nil,
-	}
+	}, err
 }
 
 func (app *Application) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	req := ParseRequest(r)
-	if req == nil {
-		http.Error(w, "Bad Request", 400)
+	req := newRequest(r)
+	if app.poolRequests {
+		defer releaseRequest(req)
+	} else {
+		defer req.removeBodyFile()
+	}
+	req.writer = w
+	req.app = app
+
+	app.inFlight.Add(1)
+	defer app.inFlight.Add(-1)
+	if resp := app.refuseWhileDraining(req); resp != nil {
+		app.finish(w, r, req, resp)
 		return
 	}
 
-	processor := app.defaultProcessor
-	path := strings.TrimRight(req.Path, "/")
-	path = req.Method + " " + path
-	for _, p := range app.processors {
-		if ok, args := p.Match(path); ok {
-			processor = p
-			req.Arguments = args
-			break
-		}
+	app.rewrite(req)
+	routes := app.table()
+	var buf [8]string
+	processor, args := routes.lookup(req.Method, strings.TrimRight(req.Path, "/"), buf[:0])
+	if len(args) > 0 {
+		req.Arguments = append([]string(nil), args...)
 	}
 
-	if processor == nil {
-		http.Error(w, "Not Found", 404)
+	if processor != nil && processor.streamBody {
+		req.streamed = true
+	} else if err := req.readBody(); err != nil {
+		app.logf("webgo: reading request body of %s %s: %v", r.Method, r.URL.Path, err)
+		app.finish(w, r, req, BadRequest(nil))
 		return
 	}
 
-	resp := processor.Process(req)
-	for name, value := range resp.Headers {
-		w.Header().Set(name, value[0])
+	handler := ProcessFunc(notFound)
+	if processor != nil {
+		req.processor = processor
+		handler = processor.Process
+	}
+	resp := app.process(handler, req)
+	resp.timings = append(req.timings, resp.timings...)
+	app.finish(w, r, req, app.devErrorResponse(req, resp))
+}
+
+// finish runs the transforms and error hooks on resp and writes it; every
+// response ServeHTTP sends goes through here.
+func (app *Application) finish(w http.ResponseWriter, r *http.Request, req *Request, resp *Response) {
+	resp = app.transform(req, resp)
+	app.reportError(req, resp)
+	app.writeResponse(w, r, resp)
+	if app.poolResponses {
+		releaseResponse(resp)
+	}
+}
+
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != 204 && status != 304
+}
+
+func (app *Application) writeResponse(w http.ResponseWriter, r *http.Request, resp *Response) {
+	if closer, ok := resp.BodyReader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	copyHeaders(w.Header(), resp.Headers)
+
+	if app.hsts != "" && r.TLS != nil {
+		w.Header().Set("Strict-Transport-Security", app.hsts)
+	}
+	if app.altSvc != "" && r.ProtoMajor < 3 {
+		w.Header().Set("Alt-Svc", app.altSvc)
+	}
+	if len(resp.timings) > 0 {
+		w.Header().Add("Server-Timing", serverTimingHeader(resp.timings))
+	}
+
+	if resp.serveHTTP != nil {
+		resp.serveHTTP(w)
+		return
+	}
+
+	if rs, ok := resp.BodyReader.(io.ReadSeeker); ok && resp.Status == 200 && resp.StreamFunc == nil {
+		http.ServeContent(w, r, resp.name, resp.modTime, rs)
+		return
+	}
+
+	header := w.Header()
+	buffered := resp.StreamFunc == nil && resp.BodyReader == nil
+	switch {
+	case !bodyAllowed(resp.Status):
+		header.Del("Content-Length")
+		header.Del("Transfer-Encoding")
+	case header.Get("Transfer-Encoding") != "":
+		header.Del("Content-Length")
+	case buffered:
+		// the buffered body is authoritative, whatever the handler claimed
+		setContentLength(header, len(resp.Body))
 	}
 	w.WriteHeader(resp.Status)
 
-	if resp.BodyReader != nil {
-		io.Copy(w, resp.BodyReader)
+	if r.Method == "HEAD" || !bodyAllowed(resp.Status) {
+		return
+	}
+
+	var err error
+	if resp.StreamFunc != nil {
+		err = resp.StreamFunc(newStreamWriter(w))
+	} else if resp.BodyReader != nil {
+		_, err = copyBuffered(w, resp.BodyReader)
 	} else {
-		w.Write(resp.Body)
+		_, err = w.Write(resp.Body)
+	}
+	if err != nil {
+		app.logf("webgo: writing response to %s %s: %v", r.Method, r.URL.Path, err)
 	}
 }
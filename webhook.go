@@ -0,0 +1,483 @@
+package webgo
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	mathrand "math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebhookEndpoint is a receiver of webhook events. Events lists the event
+// types it subscribes to; empty means all of them.
+type WebhookEndpoint struct {
+	ID     string
+	URL    string
+	Secret string
+	Events []string
+}
+
+// WebhookDelivery is one event on its way to one endpoint.
+type WebhookDelivery struct {
+	ID          string
+	EndpointID  string
+	URL         string
+	Secret      string
+	EventType   string
+	Payload     []byte
+	Attempts    int
+	NextAttempt time.Time
+	LastError   string
+}
+
+// WebhookStore persists pending deliveries, so events survive restarts.
+// Claim returns up to limit deliveries due at now and hides them from
+// other Claim calls for lease, in case several dispatchers share a store.
+// Retry stores a failed delivery's new attempt count and time; Done
+// removes one that was delivered or given up on.
+type WebhookStore interface {
+	Add(ctx context.Context, d *WebhookDelivery) error
+	Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	Retry(ctx context.Context, d *WebhookDelivery) error
+	Done(ctx context.Context, id string) error
+}
+
+// Webhooks delivers events to registered endpoints in the background. A
+// delivery is a POST of the JSON event, signed with the endpoint's secret
+// in a Webhook-Signature header (see VerifyWebhook). Failures are retried
+// with exponential backoff until MaxAttempts.
+type Webhooks struct {
+	Store  WebhookStore
+	Client *http.Client
+	Clock  Clock
+
+	// Timeout bounds each delivery attempt.
+	Timeout time.Duration
+
+	// MaxAttempts is the number of attempts before a delivery is dropped.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry; it doubles with each
+	// further attempt, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Workers is the number of deliveries made concurrently.
+	Workers int
+
+	// PollInterval is how often the store is checked for due deliveries.
+	PollInterval time.Duration
+
+	// OnFailure, if set, is called when a delivery is dropped.
+	OnFailure func(d *WebhookDelivery, err error)
+
+	mu        sync.RWMutex
+	endpoints map[string]*WebhookEndpoint
+	app       *Application
+	kick      chan struct{}
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func NewWebhooks(store WebhookStore) *Webhooks {
+	return &Webhooks{
+		Store:        store,
+		Client:       &http.Client{},
+		Clock:        SystemClock,
+		Timeout:      10 * time.Second,
+		MaxAttempts:  10,
+		Backoff:      30 * time.Second,
+		MaxBackoff:   6 * time.Hour,
+		Workers:      4,
+		PollInterval: 5 * time.Second,
+		endpoints:    make(map[string]*WebhookEndpoint),
+		kick:         make(chan struct{}, 1),
+	}
+}
+
+// UseWebhooks starts w's dispatcher with the application and stops it,
+// after finishing deliveries under way, on shutdown.
+func (app *Application) UseWebhooks(w *Webhooks) {
+	w.app = app
+	if app.clock != nil {
+		w.Clock = app.clock
+	}
+	app.OnStart(w.Start)
+	app.OnStop(w.Stop)
+}
+
+func (w *Webhooks) Register(ep WebhookEndpoint) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.endpoints[ep.ID] = &ep
+}
+
+// Unregister removes an endpoint. Deliveries already queued for it are
+// still made.
+func (w *Webhooks) Unregister(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.endpoints, id)
+}
+
+// Endpoints returns the registered endpoints, sorted by ID.
+func (w *Webhooks) Endpoints() []WebhookEndpoint {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	eps := make([]WebhookEndpoint, 0, len(w.endpoints))
+	for _, ep := range w.endpoints {
+		eps = append(eps, *ep)
+	}
+	sort.Slice(eps, func(i, j int) bool { return eps[i].ID < eps[j].ID })
+	return eps
+}
+
+type webhookEvent struct {
+	ID      string      `json:"id"`
+	Type    string      `json:"type"`
+	Created int64       `json:"created"`
+	Data    interface{} `json:"data"`
+}
+
+// Enqueue queues an event of type eventType, carrying data, for every
+// endpoint subscribed to it and returns the event's ID. It only writes to
+// the store, so it is cheap to call from handlers.
+func (w *Webhooks) Enqueue(ctx context.Context, eventType string, data interface{}) (string, error) {
+	now := w.now()
+	event := webhookEvent{ID: "evt_" + randomHex(12), Type: eventType, Created: now.Unix(), Data: data}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+
+	w.mu.RLock()
+	var targets []*WebhookEndpoint
+	for _, ep := range w.endpoints {
+		if len(ep.Events) == 0 || containsString(ep.Events, eventType) {
+			targets = append(targets, ep)
+		}
+	}
+	w.mu.RUnlock()
+
+	for _, ep := range targets {
+		d := &WebhookDelivery{
+			ID:          "dlv_" + randomHex(12),
+			EndpointID:  ep.ID,
+			URL:         ep.URL,
+			Secret:      ep.Secret,
+			EventType:   eventType,
+			Payload:     payload,
+			NextAttempt: now,
+		}
+		if err := w.Store.Add(ctx, d); err != nil {
+			return event.ID, err
+		}
+	}
+	if len(targets) > 0 {
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+	}
+	return event.ID, nil
+}
+
+// Start runs the dispatcher until Stop. It fits Application.OnStart.
+func (w *Webhooks) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		return errors.New("webgo: webhooks already started")
+	}
+	loopCtx, cancel := context.WithCancel(context.Background())
+	w.cancel, w.done = cancel, make(chan struct{})
+	go w.run(loopCtx, w.done)
+	return nil
+}
+
+// Stop ends the dispatcher, waiting for deliveries under way until ctx is
+// done. Deliveries not attempted yet stay in the store.
+func (w *Webhooks) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel = nil
+	w.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Webhooks) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	workers := w.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		lease := w.Timeout + time.Minute
+		batch, err := w.Store.Claim(ctx, w.now(), workers, lease)
+		if err != nil && ctx.Err() == nil {
+			w.logf("webgo: claiming webhook deliveries: %v", err)
+		}
+		for _, d := range batch {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(d *WebhookDelivery) {
+				defer func() { <-sem; wg.Done() }()
+				// a delivery under way is finished even when stopping
+				w.attempt(context.Background(), d)
+			}(d)
+		}
+		if len(batch) == workers {
+			// there may be more due right away
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				continue
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.kick:
+		case <-w.Clock.After(w.PollInterval):
+		}
+	}
+}
+
+func (w *Webhooks) attempt(ctx context.Context, d *WebhookDelivery) {
+	d.Attempts++
+	err := w.deliver(ctx, d)
+	if err == nil {
+		if err := w.Store.Done(ctx, d.ID); err != nil {
+			w.logf("webgo: completing webhook delivery %s: %v", d.ID, err)
+		}
+		return
+	}
+
+	var gone webhookGone
+	if d.Attempts >= w.MaxAttempts || errors.As(err, &gone) {
+		w.logf("webgo: dropping webhook delivery %s to %s after %d attempts: %v", d.ID, d.URL, d.Attempts, err)
+		if err := w.Store.Done(ctx, d.ID); err != nil {
+			w.logf("webgo: completing webhook delivery %s: %v", d.ID, err)
+		}
+		if w.OnFailure != nil {
+			w.OnFailure(d, err)
+		}
+		return
+	}
+	d.LastError = err.Error()
+	d.NextAttempt = w.now().Add(w.backoff(d.Attempts))
+	if err := w.Store.Retry(ctx, d); err != nil {
+		w.logf("webgo: rescheduling webhook delivery %s: %v", d.ID, err)
+	}
+}
+
+// webhookGone marks a 410 reply: the receiver asks for no more deliveries.
+type webhookGone struct{ error }
+
+func (w *Webhooks) deliver(ctx context.Context, d *WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(ctx, w.Timeout)
+	defer cancel()
+	r, err := http.NewRequestWithContext(ctx, "POST", d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return webhookGone{err}
+	}
+	now := w.now()
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("User-Agent", "webgo-webhooks")
+	r.Header.Set("Webhook-Id", d.ID)
+	r.Header.Set("Webhook-Event", d.EventType)
+	r.Header.Set("Webhook-Attempt", strconv.Itoa(d.Attempts))
+	r.Header.Set("Webhook-Signature", SignWebhook(d.Secret, now, d.Payload))
+
+	resp, err := w.Client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusGone:
+		return webhookGone{fmt.Errorf("endpoint replied %s", resp.Status)}
+	}
+	return fmt.Errorf("endpoint replied %s", resp.Status)
+}
+
+// backoff returns the delay before the retry following attempt n, with
+// up to 10% jitter so failed deliveries don't retry in lockstep.
+func (w *Webhooks) backoff(n int) time.Duration {
+	d := w.Backoff
+	for i := 1; i < n && d < w.MaxBackoff; i++ {
+		d *= 2
+	}
+	if w.MaxBackoff > 0 && d > w.MaxBackoff {
+		d = w.MaxBackoff
+	}
+	if d > 10 {
+		d += time.Duration(mathrand.Int63n(int64(d / 10)))
+	}
+	return d
+}
+
+func (w *Webhooks) now() time.Time {
+	return w.Clock.Now()
+}
+
+func (w *Webhooks) logf(format string, args ...interface{}) {
+	if w.app != nil {
+		w.app.logf(format, args...)
+	}
+}
+
+// SignWebhook returns the Webhook-Signature header value for payload sent
+// at t: "t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<payload>">".
+func SignWebhook(secret string, t time.Time, payload []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + webhookMAC(secret, ts, payload)
+}
+
+var ErrWebhookSignature = errors.New("invalid webhook signature")
+
+// VerifyWebhook checks a Webhook-Signature header on a received payload,
+// rejecting signatures older than tolerance to limit replays.
+func VerifyWebhook(secret, header string, payload []byte, tolerance time.Duration) error {
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrWebhookSignature
+	}
+	if tolerance > 0 {
+		if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+			return ErrWebhookSignature
+		}
+	}
+	want := webhookMAC(secret, ts, payload)
+	for _, sig := range sigs {
+		if hmac.Equal([]byte(sig), []byte(want)) {
+			return nil
+		}
+	}
+	return ErrWebhookSignature
+}
+
+func webhookMAC(secret, ts string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := cryptorand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// MemoryWebhookStore keeps pending deliveries in memory. They are lost on
+// restart; use a persistent store where that matters.
+type MemoryWebhookStore struct {
+	mu         sync.Mutex
+	deliveries map[string]*memoryDelivery
+}
+
+type memoryDelivery struct {
+	d          WebhookDelivery
+	leaseUntil time.Time
+}
+
+func NewMemoryWebhookStore() *MemoryWebhookStore {
+	return &MemoryWebhookStore{deliveries: make(map[string]*memoryDelivery)}
+}
+
+func (s *MemoryWebhookStore) Add(ctx context.Context, d *WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries[d.ID] = &memoryDelivery{d: *d}
+	return nil
+}
+
+func (s *MemoryWebhookStore) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []*memoryDelivery
+	for _, md := range s.deliveries {
+		if !md.d.NextAttempt.After(now) && !md.leaseUntil.After(now) {
+			due = append(due, md)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].d.NextAttempt.Before(due[j].d.NextAttempt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	claimed := make([]*WebhookDelivery, len(due))
+	for i, md := range due {
+		md.leaseUntil = now.Add(lease)
+		d := md.d
+		claimed[i] = &d
+	}
+	return claimed, nil
+}
+
+func (s *MemoryWebhookStore) Retry(ctx context.Context, d *WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if md, ok := s.deliveries[d.ID]; ok {
+		md.d, md.leaseUntil = *d, time.Time{}
+	}
+	return nil
+}
+
+func (s *MemoryWebhookStore) Done(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.deliveries, id)
+	return nil
+}
+
+// Pending returns the number of deliveries not yet made.
+func (s *MemoryWebhookStore) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.deliveries)
+}